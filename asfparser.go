@@ -12,17 +12,34 @@ import (
 	"bytes"
 	"io"
 	"regexp"
+	"sort"
 	"strconv"
+	"strings"
 )
 
+func init() {
+	RegisterFormat(&Format{
+		Name:       "asf",
+		MimeTypes:  []string{"video/x-ms-asf"},
+		Extensions: []string{".asf"},
+		Detect:     detectAsf,
+		New:        func(raw []byte) Playlister { return NewAsfParser(raw) },
+	})
+}
+
 // asfReg is a regular expression to match streams in a ASF playlist.
 var asfReg *regexp.Regexp = regexp.MustCompile(`(?i)ref([0-9]+)(?:\s+)?=(?:\s+)?(.*)`)
 
 // AsfParser implements ASF playlist parser.
 type AsfParser struct {
+	Diagnostics
+	UrlResolver
 	raw     []byte        // Raw contents of a playlist
 	reader  *bufio.Reader //
 	Streams []*Stream     // The array of found strams
+
+	// PreserveOrder keeps streams in file order instead of RefN order
+	PreserveOrder bool
 }
 
 // NewAsfParser returns new ASF playlist parser. Takes playlist raw content to parse.
@@ -38,36 +55,74 @@ func NewAsfParser(raw []byte) *AsfParser {
 }
 
 // Parse parses an ASF playlist.
-func (p *AsfParser) Parse() {
+func (p *AsfParser) Parse() error {
+
+	var lineNo int
+	var indexes = make(map[int]bool, 10)
 
 	for {
 		line, err := p.reader.ReadString('\n')
+		lineNo++
 
 		if err != nil && err != io.EOF {
-			break
+			p.AddDiagnostic(lineNo, 0, SeverityError, RULE_READ_ERROR, "Read error: "+err.Error(), "")
+			return &ParseError{Line: lineNo, Err: err}
 		}
 
 		idx, streamUrl := findMatch(line, asfReg)
+		text := fixString(line)
 
 		if streamUrl != "" {
 
+			if indexes[idx] {
+				p.AddDiagnostic(lineNo, 1, SeverityWarning, RULE_DUPLICATE_INDEX, "Duplicate Ref"+strconv.Itoa(idx)+" entry", text)
+			}
+			indexes[idx] = true
+
 			stream := NewStream(idx)
-			stream.Url = streamUrl
+			stream.OriginalUrl = streamUrl
+			stream.Url = p.resolveUrl(streamUrl)
+
+			if p.checkStream(&p.Diagnostics, stream, lineNo, 1, text) {
+				p.Streams = append(p.Streams, stream)
+			}
 
-			p.Streams = append(p.Streams, stream)
+		} else if text != "" && !strings.HasPrefix(text, "[") && !strings.Contains(text, "=") {
+			p.AddDiagnostic(lineNo, 1, SeverityWarning, RULE_MISSING_EQUALS, "Missing '=' in line", text)
 		}
 
 		if err == io.EOF {
 			break
 		}
 	}
+
+	checkIndexGaps(&p.Diagnostics, "Ref", indexes)
+
+	if !p.PreserveOrder {
+		sort.SliceStable(p.Streams, func(i, j int) bool {
+			return p.Streams[i].Index < p.Streams[j].Index
+		})
+	}
+
+	return nil
 }
 
-// GetStreams gets list of streams found in the playlist.
+// GetStreams gets list of streams found in the playlist ordered by RefN
+// index or in file order if PreserveOrder is set.
 func (p *AsfParser) GetStreams() []*Stream {
 	return p.Streams
 }
 
+// GetInfo gets playlist level metadata. ASF playlists have none.
+func (p *AsfParser) GetInfo() *PlaylistInfo {
+	return new(PlaylistInfo)
+}
+
+// SetPreserveOrder sets PreserveOrder.
+func (p *AsfParser) SetPreserveOrder(preserve bool) {
+	p.PreserveOrder = preserve
+}
+
 // findMatch checks if the line matches the regular expression for stream URL.
 func findMatch(line string, reg *regexp.Regexp) (idx int, stream string) {
 
@@ -82,3 +137,8 @@ func findMatch(line string, reg *regexp.Regexp) (idx int, stream string) {
 
 	return
 }
+
+// detectAsf returns true if playlist is an ASF reference playlist.
+func detectAsf(header string, raw []byte) bool {
+	return header == "[reference]"
+}
@@ -148,3 +148,23 @@ func BenchmarkAsfParsing(b *testing.B) {
 		parser.Parse()
 	}
 }
+
+func TestAsfOrder(t *testing.T) {
+
+	raw := []byte("[Reference]\nRef2=http://ex.com/2\nRef1=http://ex.com/1\n")
+
+	parser := NewAsfParser(raw)
+	parser.Parse()
+
+	if len(parser.Streams) != 2 || parser.Streams[0].Index != 1 || parser.Streams[1].Index != 2 {
+		t.Fatalf("Expected streams ordered by index got %v", parser.Streams)
+	}
+
+	parser = NewAsfParser(raw)
+	parser.PreserveOrder = true
+	parser.Parse()
+
+	if len(parser.Streams) != 2 || parser.Streams[0].Index != 2 || parser.Streams[1].Index != 1 {
+		t.Fatalf("Expected streams in file order got %v", parser.Streams)
+	}
+}
@@ -8,217 +8,741 @@
 package plparser
 
 import (
-	"reflect"
-	"regexp"
+	"bytes"
+	"context"
+	"encoding/xml"
+	"io"
+	"io/ioutil"
+	"net/url"
+	"path/filepath"
+	"strconv"
 	"strings"
+	"time"
 )
 
-// asxRegs are regular expressions to catch various ASX playlist elements.
-var asxRegs = []struct {
-	name string
-	reg  *regexp.Regexp
-}{
-	{"Description", regexp.MustCompile(`(?is)<abstract(?:\s+)?>(.*?)</abstract(?:\s+)?>`)},
-	{"Title", regexp.MustCompile(`(?is)<title(?:\s+)?>(.*?)</title(?:\s+)?>`)},
-	{"Logo", regexp.MustCompile(`(?i)<logo(?:\s+)?href(?:\s+)?=(?:\s+)?(?:"|')(.*?)(?:"|')(?:.*?)/>`)},
-	{"Author", regexp.MustCompile(`(?is)<author(?:\s+)?>(.*?)</author(?:\s+)?>`)},
-	{"Copyright", regexp.MustCompile(`(?is)<copyright(?:\s+)?>(.*?)</copyright(?:\s+)?>`)},
-	{"Url", regexp.MustCompile(`(?i)<ref(?:\s+)?href(?:\s+)?=(?:\s+)?(?:"|')(.*?)(?:"|')(?:.*?)/?>(?:</ref(?:\s+)?>)?`)},
-	{"Base", regexp.MustCompile(`(?i)<base(?:\s+)?href(?:\s+)?=(?:\s+)?(?:"|')(.*?)(?:"|')(?:.*?)/?>(?:</base(?:\s+)?>)?`)},
-	{"MoreInfo", regexp.MustCompile(`(?i)<moreinfo(?:\s+)?href(?:\s+)?=(?:\s+)?(?:"|')(.*?)(?:"|')(?:.*?)/?>(?:</moreinfo(?:\s+)?>)?`)},
+func init() {
+	RegisterFormat(&Format{
+		Name:       "asx",
+		MimeTypes:  []string{"video/x-ms-asf", "audio/x-ms-wax", "video/x-ms-wvx"},
+		Extensions: []string{".asx", ".wax", ".wvx"},
+		Detect:     detectAsx,
+		New:        func(raw []byte) Playlister { return NewAsxParser(raw) },
+	})
 }
 
-// asxEntityRegExp regular expression to find all ENTRY elements.
-var asxEntityRegExp *regexp.Regexp = regexp.MustCompile(`(?is)<entry(?:\s+)?>(.*?)</entry(?:\s+)?>`)
+// asxVoidElements are ASX elements with no content. They are closed
+// when other element starts even if they have no closing tag.
+var asxVoidElements = map[string]bool{
+	"ref":       true,
+	"entryref":  true,
+	"base":      true,
+	"logo":      true,
+	"moreinfo":  true,
+	"param":     true,
+	"duration":  true,
+	"starttime": true,
+}
+
+// asxContainerElements are ASX elements reported when they are not closed.
+var asxContainerElements = map[string]bool{
+	"asx":    true,
+	"entry":  true,
+	"repeat": true,
+	"event":  true,
+}
+
+// AsxLoader loads ASX playlist referenced by ENTRYREF element. Takes
+// ENTRYREF href resolved against BASE and the playlist location.
+type AsxLoader func(url string) ([]byte, error)
+
+// AsxLoaderSetter is implemented by parsers which follow ENTRYREF elements.
+type AsxLoaderSetter interface {
+	// SetAsxLoader sets the ENTRYREF loader. Nil means ENTRYREF elements
+	// are not followed. Must be called before Parse.
+	SetAsxLoader(loader AsxLoader)
+}
+
+// AsxMeta holds metadata elements common to ASX and ENTRY elements.
+type AsxMeta struct {
+	Title     string
+	Author    string
+	Copyright string
+	Abstract  string
+	Base      string
+	Logo      string
+	MoreInfo  string
+	Banner    *AsxBanner
+	Params    []*AsxParam
+}
+
+// AsxBanner represents BANNER element.
+type AsxBanner struct {
+	Href     string
+	Abstract string
+	MoreInfo string
+}
+
+// AsxParam represents PARAM element.
+type AsxParam struct {
+	Name  string
+	Value string
+}
+
+// AsxRef represents REF element.
+type AsxRef struct {
+	Href   string
+	Line   int
+	Column int
+	text   string // Source of the start tag
+}
+
+// AsxEntry represents ENTRY element.
+type AsxEntry struct {
+	AsxMeta
+	ClientSkip string
+	Duration   time.Duration // Zero if not set
+	StartTime  time.Duration
+	Refs       []*AsxRef
+	Line       int
+	Column     int
+}
+
+// AsxEntryRef represents ENTRYREF element.
+type AsxEntryRef struct {
+	Href       string
+	ClientBind string
+	Line       int
+	Column     int
+	Url        string       // Href resolved against BASE and the playlist location
+	Document   *AsxDocument // Referenced document, nil if it was not loaded
+	text       string       // Source of the start tag
+}
+
+// AsxRepeat represents REPEAT element.
+type AsxRepeat struct {
+	Count int // -1 if repeated indefinitely
+	Items []*AsxItem
+}
+
+// AsxEvent represents EVENT element.
+type AsxEvent struct {
+	Name     string
+	WhenDone string
+	Items    []*AsxItem
+}
+
+// AsxItem is one of the elements which may appear in the ASX playlist
+// body in document order. Only one of the fields is set.
+type AsxItem struct {
+	Entry    *AsxEntry
+	EntryRef *AsxEntryRef
+	Repeat   *AsxRepeat
+	Event    *AsxEvent
+}
+
+// AsxDocument represents ASX document.
+type AsxDocument struct {
+	AsxMeta
+	Version string
+	Items   []*AsxItem
+}
+
+// asxNode is an element of tolerantly parsed XML tree.
+type asxNode struct {
+	name     string            // Lowercased local name
+	attrs    map[string]string // Lowercased attribute names
+	text     string
+	children []*asxNode
+	line     int
+	column   int
+	source   string // Source of the start tag
+}
+
+// attr returns trimmed attribute value.
+func (n *asxNode) attr(name string) string {
+	return strings.TrimSpace(n.attrs[name])
+}
 
 // AsxParser implements ASX playlist parser.
 type AsxParser struct {
-	raw         string
+	Diagnostics
+	UrlResolver
+	raw         []byte
 	Author      string
 	Base        string
 	Copyright   string
 	Description string
+	Document    *AsxDocument
 	Logo        string
 	MoreInfo    string
 	Streams     []*Stream
 	Title       string
+	Version     string
+	loader      AsxLoader
+	refChain    []string // Playlists the ENTRYREF elements were followed from
+	entries     int      // Number of entries including referenced ones
 }
 
 // NewAsxParser returns new ASX playlist parser. Takes playlist raw content to parse.
 func NewAsxParser(raw []byte) *AsxParser {
 	asx := new(AsxParser)
-	asx.raw = string(raw)
+	asx.raw = raw
 	asx.Streams = make([]*Stream, 0, 10)
 	return asx
 }
 
-// Parse parses an ASX playlist.
-func (a *AsxParser) Parse() {
+// Parse parses an ASX playlist. The parser is tolerant: element names
+// are case insensitive and not closed elements are closed by the end
+// tag of any of their parents.
+func (a *AsxParser) Parse() error {
 
-	// Get all the entries that represent streams
-	entries := asxEntityRegExp.FindAllStringSubmatch(a.raw, -1)
+	root := a.parseTree()
+	if root == nil {
+		return nil
+	}
 
-	// Remove parser entries from ASX
-	// This will simplify parsing the main body of the
-	// playlist
-	a.raw = asxEntityRegExp.ReplaceAllString(a.raw, "")
+	a.Document = a.buildDocument(root)
 
-	// Main body of the playlist may have the same
-	// element names as ENTRY except REF (Url)
-	for _, s := range asxRegs {
+	a.Title = a.Document.Title
+	a.Author = a.Document.Author
+	a.Copyright = a.Document.Copyright
+	a.Description = a.Document.Abstract
+	a.Logo = a.Document.Logo
+	a.MoreInfo = a.Document.MoreInfo
+	a.Base = a.Document.Base
+	a.Version = a.Document.Version
 
-		// We skip URLs.
-		// We first have to parse main body.
-		if s.name == "Url" {
-			continue
+	a.parseItems(a.Document.Items, 0)
+
+	return nil
+}
+
+// SetAsxLoader sets the loader of playlists referenced by ENTRYREF elements.
+func (a *AsxParser) SetAsxLoader(loader AsxLoader) {
+	a.loader = loader
+}
+
+// parseTree parses raw content to the tree of elements. Returns the
+// root element or nil if the document has no elements.
+func (a *AsxParser) parseTree() *asxNode {
+
+	// Content is transcoded up front so decoder offsets
+	// point to the same bytes as in raw
+	raw := xmlToUtf8(a.raw)
+
+	decoder := xml.NewDecoder(bytes.NewReader(raw))
+	decoder.Strict = false
+	decoder.Entity = xml.HTMLEntity
+	decoder.CharsetReader = xmlCharsetReader
+
+	// Stack of open elements, the first one is a document node
+	stack := []*asxNode{{}}
+
+	for {
+		line, column := decoder.InputPos()
+		start := decoder.InputOffset()
+
+		token, err := decoder.RawToken()
+		if err == io.EOF {
+			break
 		}
 
-		values := s.reg.FindStringSubmatch(a.raw)
-		if len(values) != 2 {
-			continue
+		if err != nil {
+			a.AddDiagnostic(line, column, SeverityError, RULE_MALFORMED_XML, "Malformed XML: "+err.Error(), "")
+			break
 		}
 
-		a.setValue(s.name, values[1])
+		top := stack[len(stack)-1]
+
+		switch t := token.(type) {
+
+		case xml.StartElement:
+			node := &asxNode{
+				name:   strings.ToLower(t.Name.Local),
+				attrs:  make(map[string]string, len(t.Attr)),
+				line:   line,
+				column: column,
+				source: string(raw[start:decoder.InputOffset()]),
+			}
+
+			for _, attr := range t.Attr {
+				node.attrs[strings.ToLower(attr.Name.Local)] = attr.Value
+			}
+
+			// Elements with no content are closed by any other element
+			if asxVoidElements[top.name] && len(stack) > 1 {
+				stack = stack[:len(stack)-1]
+				top = stack[len(stack)-1]
+			}
+
+			top.children = append(top.children, node)
+			stack = append(stack, node)
+
+		case xml.EndElement:
+			name := strings.ToLower(t.Name.Local)
+
+			// Close the element and all not closed elements inside it.
+			// End tags with no matching start tag are ignored.
+			for i := len(stack) - 1; i > 0; i-- {
+				if stack[i].name != name {
+					continue
+				}
+
+				for _, n := range stack[i+1:] {
+					a.checkClosed(n)
+				}
+
+				stack = stack[:i]
+				break
+			}
+
+		case xml.CharData:
+			top.text += string(t)
+		}
 	}
 
-	// Main body of the playlist has been parsed.
-	// We parsed main body first to get BASE value if it exists.
-	a.parseEntries(entries)
+	for _, n := range stack[1:] {
+		a.checkClosed(n)
+	}
+
+	if len(stack[0].children) == 0 {
+		return nil
+	}
+
+	return stack[0].children[0]
 }
 
-// GetStreams gets list of streams found in the playlist.
-func (p *AsxParser) GetStreams() []*Stream {
-	return p.Streams
+// checkClosed adds diagnostic for container element with no closing tag.
+func (a *AsxParser) checkClosed(n *asxNode) {
+	if asxContainerElements[n.name] {
+		a.AddDiagnostic(n.line, n.column, SeverityError, RULE_ASX_UNCLOSED_ENTRY, "Unclosed <"+n.name+"> element", n.source)
+	}
+}
+
+// buildDocument builds ASX document model from the root element.
+func (a *AsxParser) buildDocument(root *asxNode) *AsxDocument {
+
+	doc := new(AsxDocument)
+	doc.Version = root.attr("version")
+
+	if doc.Version == "" {
+		a.AddFinding(root.line, root.column, SeverityWarning, RULE_ASX_MISSING_VERSION, "Missing version attribute", root.source)
+	}
+
+	for _, n := range root.children {
+		if !a.buildMeta(&doc.AsxMeta, n) {
+			a.buildItem(&doc.Items, n)
+		}
+	}
+
+	return doc
 }
 
-// setValue sets AsxParser structure value by name.
-func (a *AsxParser) setValue(fieldName, value string) {
-	reflect.ValueOf(a).Elem().FieldByName(fieldName).SetString(value)
+// buildMeta sets metadata from the element. Returns false if element
+// is not a metadata element.
+func (a *AsxParser) buildMeta(meta *AsxMeta, n *asxNode) bool {
+
+	switch n.name {
+	case "title":
+		meta.Title = fixString(n.text)
+	case "author":
+		meta.Author = fixString(n.text)
+	case "copyright":
+		meta.Copyright = fixString(n.text)
+	case "abstract":
+		meta.Abstract = fixString(n.text)
+	case "base":
+		meta.Base = n.attr("href")
+	case "logo":
+		meta.Logo = n.attr("href")
+	case "moreinfo":
+		meta.MoreInfo = n.attr("href")
+	case "param":
+		meta.Params = append(meta.Params, &AsxParam{Name: n.attr("name"), Value: n.attr("value")})
+	case "banner":
+		meta.Banner = &AsxBanner{Href: n.attr("href")}
+		for _, c := range n.children {
+			switch c.name {
+			case "abstract":
+				meta.Banner.Abstract = fixString(c.text)
+			case "moreinfo":
+				meta.Banner.MoreInfo = c.attr("href")
+			}
+		}
+	default:
+		return false
+	}
+
+	return true
 }
 
-// getValue gets AsxParser structure field by name.
-func (a *AsxParser) getValue(fieldName string) string {
-	return reflect.ValueOf(a).Elem().FieldByName(fieldName).String()
+// buildItem appends playlist body element to items.
+func (a *AsxParser) buildItem(items *[]*AsxItem, n *asxNode) {
+
+	switch n.name {
+
+	case "entry":
+		*items = append(*items, &AsxItem{Entry: a.buildEntry(n)})
+
+	case "entryref":
+		*items = append(*items, &AsxItem{EntryRef: &AsxEntryRef{
+			Href:       n.attr("href"),
+			ClientBind: n.attr("clientbind"),
+			Line:       n.line,
+			Column:     n.column,
+			text:       n.source,
+		}})
+
+	case "repeat":
+		repeat := &AsxRepeat{Count: -1}
+		if count, err := strconv.Atoi(n.attr("count")); err == nil {
+			repeat.Count = count
+		}
+
+		for _, c := range n.children {
+			a.buildItem(&repeat.Items, c)
+		}
+
+		*items = append(*items, &AsxItem{Repeat: repeat})
+
+	case "event":
+		event := &AsxEvent{Name: n.attr("name"), WhenDone: n.attr("whendone")}
+
+		for _, c := range n.children {
+			a.buildItem(&event.Items, c)
+		}
+
+		*items = append(*items, &AsxItem{Event: event})
+
+	case "ref":
+		a.AddFinding(n.line, n.column, SeverityWarning, RULE_ASX_REF_OUTSIDE_ENTRY, "REF element outside of ENTRY", n.source)
+	}
 }
 
-// parseAsx parses ENTRY nodes in the ASX playlist.
-func (s *Stream) parseAsx(asxp *AsxParser) []*Stream {
+// buildEntry builds ENTRY element model.
+func (a *AsxParser) buildEntry(n *asxNode) *AsxEntry {
 
-	// Regular expression to match stream URL
-	var urlr *regexp.Regexp
+	entry := &AsxEntry{ClientSkip: n.attr("clientskip"), Line: n.line, Column: n.column}
 
-	// First we parse all the info we can get except
-	// the URL to a stream
-	for _, reg := range asxRegs {
+	for _, c := range n.children {
 
-		// We do this one last
-		if reg.name == "Url" {
-			urlr = reg.reg
+		if a.buildMeta(&entry.AsxMeta, c) {
 			continue
 		}
 
-		values := reg.reg.FindStringSubmatch(s.raw)
+		switch c.name {
+		case "ref":
+			entry.Refs = append(entry.Refs, &AsxRef{Href: c.attr("href"), Line: c.line, Column: c.column, text: c.source})
+		case "duration":
+			entry.Duration = parseAsxTime(c.attr("value"))
+		case "starttime":
+			entry.StartTime = parseAsxTime(c.attr("value"))
+		}
+	}
 
-		var value string
+	return entry
+}
 
-		if len(values) == 2 {
-			value = values[1]
-		} else {
-			value = asxp.getValue(reg.name)
+// parseItems creates streams from ENTRY elements in items and from
+// playlists referenced by ENTRYREF elements. Takes the count of the
+// innermost REPEAT element the items are in.
+func (a *AsxParser) parseItems(items []*AsxItem, repeat int) {
+
+	for _, item := range items {
+		switch {
+		case item.Entry != nil:
+			a.entries++
+			a.parseEntry(item.Entry, a.entries, repeat)
+		case item.EntryRef != nil:
+			a.parseEntryRef(item.EntryRef, repeat)
+		case item.Repeat != nil:
+			a.parseItems(item.Repeat.Items, item.Repeat.Count)
+		case item.Event != nil:
+			a.parseItems(item.Event.Items, repeat)
 		}
+	}
+}
 
-		s.setValue(reg.name, value)
+// parseEntryRef loads playlist referenced by ENTRYREF element and
+// appends its streams. Entries of the referenced playlist are numbered
+// after the entries found so far.
+func (a *AsxParser) parseEntryRef(ref *AsxEntryRef, repeat int) {
+
+	if a.loader == nil || ref.Href == "" {
+		return
 	}
 
-	// Inherit base for URLs from main playlist body
-	if s.Base != "" {
-		s.Base = asxp.Base
+	ref.Url = a.resolveUrl(ref.Href, asxBase(a.Document.Base))
+
+	chain := a.refChain
+	if a.baseUrl != "" {
+		chain = append(chain[:len(chain):len(chain)], a.baseUrl)
 	}
 
-	// Make sure the base ends with "/"
-	if s.Base != "" && !strings.HasSuffix(s.Base, "/") {
-		s.Base += "/"
+	if inChain(ref.Url, chain) {
+		a.AddDiagnostic(ref.Line, ref.Column, SeverityWarning, RULE_ASX_ENTRYREF_CYCLE, "ENTRYREF points back to the playlist it came through", ref.text)
+		return
 	}
 
-	// Find all the stream URLs
-	streams := urlr.FindAllStringSubmatch(s.raw, -1)
+	if len(chain) >= DefaultMaxDepth {
+		a.AddDiagnostic(ref.Line, ref.Column, SeverityWarning, RULE_ASX_ENTRYREF_CYCLE, "ENTRYREF nested too deep", ref.text)
+		return
+	}
 
-	var streamsToAdd []*Stream = make([]*Stream, 0, 10)
+	raw, err := a.loader(ref.Url)
+	if err != nil {
+		a.AddDiagnostic(ref.Line, ref.Column, SeverityWarning, RULE_ASX_ENTRYREF_FAILED, "Error loading ENTRYREF: "+err.Error(), ref.text)
+		return
+	}
 
-	for _, stream := range streams {
+	if utf8, err := ToUtf8(raw, DetectCharset(raw, "")); err == nil {
+		raw = setXmlEncoding(utf8, CHARSET_UTF8)
+	}
 
-		newStream := s.makeCopy()
+	nested := NewAsxParser(raw)
+	nested.SetBaseUrl(ref.Url)
+	nested.SetSchemePolicy(a.schemePolicy)
+	nested.loader = a.loader
+	nested.refChain = chain
+	nested.Parse()
 
-		// Prefix base URL to the stream URL
-		if newStream.Base != "" {
-			stream[1] = newStream.Base + stream[1]
+	if nested.Document == nil {
+		a.AddDiagnostic(ref.Line, ref.Column, SeverityWarning, RULE_ASX_ENTRYREF_FAILED, "ENTRYREF is not an ASX playlist", ref.text)
+		return
+	}
+
+	ref.Document = nested.Document
+
+	for _, s := range nested.Streams {
+		s.Index += a.entries
+		s.Chain = append([]string{ref.Url}, s.Chain...)
+
+		// Streams in REPEAT of the referenced playlist keep its count
+		if s.Repeat == 0 {
+			s.Repeat = repeat
 		}
 
-		newStream.Url = stream[1]
-		streamsToAdd = append(streamsToAdd, newStream)
+		a.Streams = append(a.Streams, s)
 	}
 
-	return streamsToAdd
+	a.entries += nested.entries
 }
 
-// parseEntries parses ENTRY elements.
-func (a *AsxParser) parseEntries(entries [][]string) {
+// parseEntry creates stream from REF elements of the entry. The first
+// accepted REF is the stream URL, the others are its fallbacks. Metadata
+// missing in the entry is inherited from the main playlist body.
+func (a *AsxParser) parseEntry(entry *AsxEntry, idx, repeat int) {
+
+	doc := a.Document
+
+	s := NewStream(idx)
+	s.Title = asxValue(entry.Title, doc.Title)
+	s.Description = asxValue(entry.Abstract, doc.Abstract)
+	s.Logo = asxValue(entry.Logo, doc.Logo)
+	s.Author = asxValue(entry.Author, doc.Author)
+	s.Copyright = asxValue(entry.Copyright, doc.Copyright)
+	s.MoreInfo = asxValue(entry.MoreInfo, doc.MoreInfo)
+	s.Duration = entry.Duration
+	s.Repeat = repeat
+
+	// PARAM elements of the main playlist body apply to every
+	// entry, the entry ones override them
+	for _, param := range doc.Params {
+		setAsxParam(s, param)
+	}
 
-	// Regular expression to match stream URL
-	var urlr *regexp.Regexp
-	var s *Stream
+	for _, param := range entry.Params {
+		setAsxParam(s, param)
+	}
 
-	// Go over all found entries
-	for idx, entry := range entries {
+	// Inherit base for URLs from main playlist body
+	base := asxBase(asxValue(entry.Base, doc.Base))
 
-		s = NewStream(idx)
+	var primary *Stream
 
-		// First we parse all the info we can get except the URL to a stream
-		for _, reg := range asxRegs {
+	for _, ref := range entry.Refs {
 
-			// We do this one last
-			if reg.name == "Url" {
-				urlr = reg.reg
-				continue
-			}
+		newStream := s.makeCopy()
+		newStream.OriginalUrl = ref.Href
+		newStream.Url = a.resolveUrl(ref.Href, base)
 
-			values := reg.reg.FindStringSubmatch(entry[1])
+		if !a.checkStream(&a.Diagnostics, newStream, ref.Line, ref.Column, ref.text) {
+			continue
+		}
 
-			var value string
+		if primary == nil {
+			primary = newStream
+			a.Streams = append(a.Streams, primary)
+		} else {
+			primary.Fallbacks = append(primary.Fallbacks, newStream.Url)
+		}
+	}
+}
+
+// GetStreams gets list of streams found in the playlist.
+func (a *AsxParser) GetStreams() []*Stream {
+	return a.Streams
+}
 
-			if len(values) == 2 {
-				value = values[1]
-			} else {
-				value = a.getValue(reg.name)
+// GetInfo gets playlist level metadata.
+func (a *AsxParser) GetInfo() *PlaylistInfo {
+
+	info := &PlaylistInfo{
+		Title:       a.Title,
+		Author:      a.Author,
+		Copyright:   a.Copyright,
+		Description: a.Description,
+		Logo:        a.Logo,
+		MoreInfo:    a.MoreInfo,
+		Base:        a.Base,
+		Version:     a.Version,
+	}
+
+	if a.Document != nil && len(a.Document.Params) > 0 {
+		info.Attributes = make(map[string]string, len(a.Document.Params))
+		for _, param := range a.Document.Params {
+			if param.Name != "" {
+				info.Attributes[param.Name] = param.Value
 			}
+		}
+	}
+
+	return info
+}
+
+// setAsxParam sets PARAM element as stream attribute. Well known
+// parameters are also set on dedicated stream fields.
+func setAsxParam(s *Stream, param *AsxParam) {
+
+	if param.Name == "" {
+		return
+	}
 
-			s.setValue(reg.name, value)
+	if s.Attributes == nil {
+		s.Attributes = make(map[string]string, 4)
+	}
+
+	// PARAM names are case insensitive
+	for name := range s.Attributes {
+		if strings.EqualFold(name, param.Name) {
+			delete(s.Attributes, name)
 		}
+	}
+	s.Attributes[param.Name] = param.Value
+
+	switch strings.ToLower(param.Name) {
+	case "genre":
+		s.Genre = param.Value
+	case "location":
+		s.Location = param.Value
+	case "homepage":
+		s.Homepage = param.Value
+	case "htmlview":
+		s.HTMLView = param.Value
+	}
+}
 
-		// Inherit base for URLs from main playlist body
-		if s.Base != "" {
-			s.Base = a.Base
+// asxBase returns ASX BASE value usable for resolving URLs. ASX BASE
+// is prefixed to relative URLs so it must end with "/".
+func asxBase(base string) string {
+	if base != "" && !strings.HasSuffix(base, "/") {
+		base += "/"
+	}
+
+	return base
+}
+
+// NewFileAsxLoader returns ENTRYREF loader reading playlists from local
+// files. Takes the directory relative paths are resolved against.
+// Accepts paths and file URLs.
+func NewFileAsxLoader(dir string) AsxLoader {
+	return func(ref string) ([]byte, error) {
+
+		path := ref
+		if isAbsUrl(ref) {
+			u, _ := url.Parse(ref)
+			if u.Scheme != "file" {
+				return nil, newError(ErrUnsupportedScheme, ref, nil)
+			}
+			path = u.Path
 		}
 
-		// Make sure the base ends with "/"
-		if s.Base != "" && !strings.HasSuffix(s.Base, "/") {
-			s.Base += "/"
+		if !filepath.IsAbs(path) {
+			path = filepath.Join(dir, filepath.FromSlash(path))
 		}
 
-		// Find all the stream URLs
-		streams := urlr.FindAllStringSubmatch(entry[1], -1)
+		return ioutil.ReadFile(path)
+	}
+}
 
-		for _, stream := range streams {
+// NewFetcherAsxLoader returns ENTRYREF loader fetching playlists with
+// the fetcher. The ctx controls the deadline and cancellation of fetches.
+func NewFetcherAsxLoader(ctx context.Context, f *Fetcher) AsxLoader {
+	return func(ref string) ([]byte, error) {
 
-			newStream := s.makeCopy()
+		plr, err := f.Fetch(ctx, ref)
+		if err != nil {
+			return nil, err
+		}
 
-			// Prefix base URL to the stream URL
-			if newStream.Base != "" {
-				stream[1] = newStream.Base + stream[1]
+		return plr.Raw, nil
+	}
+}
+
+// asxValue returns value or inherited value if value is empty.
+func asxValue(value, inherited string) string {
+	if value == "" {
+		return inherited
+	}
+
+	return value
+}
+
+// parseAsxTime parses ASX time value in form of [[hh:]mm:]ss[.fract].
+// Returns zero for invalid values.
+func parseAsxTime(value string) time.Duration {
+
+	var d time.Duration
+
+	parts := strings.Split(strings.TrimSpace(value), ":")
+	if len(parts) > 3 {
+		return 0
+	}
+
+	for i, part := range parts {
+
+		// Only seconds may have a fraction
+		if i < len(parts)-1 {
+			n, err := strconv.Atoi(part)
+			if err != nil {
+				return 0
 			}
+			d = d*60 + time.Duration(n)*time.Second
+			continue
+		}
 
-			newStream.Url = stream[1]
-			a.Streams = append(a.Streams, newStream)
+		secs, err := strconv.ParseFloat(part, 64)
+		if err != nil {
+			return 0
 		}
+		d = d*60 + time.Duration(secs*float64(time.Second))
+	}
+
+	return d
+}
+
+// detectAsx returns true if playlist is an ASX playlist.
+func detectAsx(header string, raw []byte) bool {
+
+	if strings.HasPrefix(header, "<asx") {
+		return true
 	}
+
+	// ASX may start with the XML prolog
+	if strings.HasPrefix(header, "<?xml") {
+		name, _ := xmlRoot(raw)
+		return name == "asx"
+	}
+
+	return false
 }
@@ -9,172 +9,151 @@ package plparser
 
 import (
 	// "fmt"
+	"path/filepath"
 	"regexp"
 	"strconv"
+	"strings"
 	"testing"
+	"time"
 )
 
-func TestAsxRegExp(t *testing.T) {
-
-	var descreg, titlereg, logoreg, authorreg, copyreg, urlreg *regexp.Regexp
-	var basereg, morereg *regexp.Regexp
-
-	// Extract regular expressions
-	for _, reg := range asxRegs {
-
-		switch reg.name {
-
-		case "Description":
-			descreg = reg.reg
-
-		case "Title":
-			titlereg = reg.reg
-
-		case "Logo":
-			logoreg = reg.reg
-
-		case "Author":
-			authorreg = reg.reg
-
-		case "Copyright":
-			copyreg = reg.reg
-
-		case "Url":
-			urlreg = reg.reg
-
-		case "Base":
-			basereg = reg.reg
-
-		case "MoreInfo":
-			morereg = reg.reg
-		}
-	}
-
-	// Regular expression rules
-	var testRuls = []struct {
-		reg      *regexp.Regexp
+func TestAsxElements(t *testing.T) {
+
+	// Getters of tested values from the first ENTRY element
+	var (
+		abstract  = func(e *AsxEntry) string { return e.Abstract }
+		title     = func(e *AsxEntry) string { return e.Title }
+		logo      = func(e *AsxEntry) string { return e.Logo }
+		author    = func(e *AsxEntry) string { return e.Author }
+		copyright = func(e *AsxEntry) string { return e.Copyright }
+		href      = func(e *AsxEntry) string { return e.Refs[0].Href }
+		base      = func(e *AsxEntry) string { return e.Base }
+		moreInfo  = func(e *AsxEntry) string { return e.MoreInfo }
+	)
+
+	// Elements and values expected from them
+	var tests = []struct {
+		getter   func(e *AsxEntry) string
 		line     string
 		expected string
 		matches  int
 	}{
 		// Description (Abstract)
-		{descreg, "<ABSTRACT>aaaa</ABSTRACT>", "aaaa", 1},
-		{descreg, "<AbstracT>aaaa</AbStRaCt>", "aaaa", 1},
-		{descreg, "<abstract>aaaa</abstract>", "aaaa", 1},
-		{descreg, "<abstract>aaaa</ABSTRACT>", "aaaa", 1},
-		{descreg, "<ABSTRACT>aaaa</abstract>", "aaaa", 1},
-		{descreg, "<ABSTRACT  >aaaa</abstract  >", "aaaa", 1},
-		{descreg, "<ABSTRACT  >aaaa</abstract>", "aaaa", 1},
+		{abstract, "<ABSTRACT>aaaa</ABSTRACT>", "aaaa", 1},
+		{abstract, "<AbstracT>aaaa</AbStRaCt>", "aaaa", 1},
+		{abstract, "<abstract>aaaa</abstract>", "aaaa", 1},
+		{abstract, "<abstract>aaaa</ABSTRACT>", "aaaa", 1},
+		{abstract, "<ABSTRACT>aaaa</abstract>", "aaaa", 1},
+		{abstract, "<ABSTRACT  >aaaa</abstract  >", "aaaa", 1},
+		{abstract, "<ABSTRACT  >aaaa</abstract>", "aaaa", 1},
 
 		// Title
-		{titlereg, "<TITLE>aaaa</TITLE>", "aaaa", 1},
-		{titlereg, "<TitlE>aaaa</TiTlE>", "aaaa", 1},
-		{titlereg, "<title>aaaa</title>", "aaaa", 1},
-		{titlereg, "<title>aaaa</TITLE>", "aaaa", 1},
-		{titlereg, "<TITLE >aaaa</TITLE >", "aaaa", 1},
-		{titlereg, "<TITLE  >aaaa</TITLE>", "aaaa", 1},
-		{titlereg, "<TITLE  >aaaa</TiTle>", "aaaa", 1},
+		{title, "<TITLE>aaaa</TITLE>", "aaaa", 1},
+		{title, "<TitlE>aaaa</TiTlE>", "aaaa", 1},
+		{title, "<title>aaaa</title>", "aaaa", 1},
+		{title, "<title>aaaa</TITLE>", "aaaa", 1},
+		{title, "<TITLE >aaaa</TITLE >", "aaaa", 1},
+		{title, "<TITLE  >aaaa</TITLE>", "aaaa", 1},
+		{title, "<TITLE  >aaaa</TiTle>", "aaaa", 1},
 
 		// Logo
-		{logoreg, "<LOGO href='aaaa' Style='BANNER'/>", "aaaa", 1},
-		{logoreg, "<LOGO Href='aaaa' Style=\"BANNER\"/>", "aaaa", 1},
-		{logoreg, "<LOGO href=\"aaaa\" Style=\"BANNER\"/>", "aaaa", 1},
-		{logoreg, "<LOGO Href = 'aaaa' Style='BANNER'/>", "aaaa", 1},
-		{logoreg, "<LOGO  href ='aaaa' Style='BANNER'  />", "aaaa", 1},
-		{logoreg, "<logo href= 'aaaa' Style='BANNER'  />", "aaaa", 1},
+		{logo, "<LOGO href='aaaa' Style='BANNER'/>", "aaaa", 1},
+		{logo, "<LOGO Href='aaaa' Style=\"BANNER\"/>", "aaaa", 1},
+		{logo, "<LOGO href=\"aaaa\" Style=\"BANNER\"/>", "aaaa", 1},
+		{logo, "<LOGO Href = 'aaaa' Style='BANNER'/>", "aaaa", 1},
+		{logo, "<LOGO  href ='aaaa' Style='BANNER'  />", "aaaa", 1},
+		{logo, "<logo href= 'aaaa' Style='BANNER'  />", "aaaa", 1},
 
 		// Author
-		{authorreg, "<AUTHOR>aaaa</AUTHOR>", "aaaa", 1},
-		{authorreg, "<author>aaaa</AUTHOR>", "aaaa", 1},
-		{authorreg, "<author>aaaa</author>", "aaaa", 1},
-		{authorreg, "<author >aaaa</author >", "aaaa", 1},
-		{authorreg, "<author  >aaaa</author  >", "aaaa", 1},
-		{authorreg, "<author>aaaa</author  >", "aaaa", 1},
+		{author, "<AUTHOR>aaaa</AUTHOR>", "aaaa", 1},
+		{author, "<author>aaaa</AUTHOR>", "aaaa", 1},
+		{author, "<author>aaaa</author>", "aaaa", 1},
+		{author, "<author >aaaa</author >", "aaaa", 1},
+		{author, "<author  >aaaa</author  >", "aaaa", 1},
+		{author, "<author>aaaa</author  >", "aaaa", 1},
 
 		// Copyright
-		{copyreg, "<COPYRIGHT>aaaa</COPYRIGHT>", "aaaa", 1},
-		{copyreg, "<COPYRIGHT >aaaa</COPYRIGHT>", "aaaa", 1},
-		{copyreg, "<COPYRIGHT >aaaa</COPYRIGHT >", "aaaa", 1},
-		{copyreg, "<COPYRIGHT  >aaaa</COPYRIGHT  >", "aaaa", 1},
-		{copyreg, "<copyright  >aaaa</COPYRIGHT  >", "aaaa", 1},
-		{copyreg, "<copyright  >aaaa</copyright  >", "aaaa", 1},
-		{copyreg, "<Copyright  >aaaa</Copyright  >", "aaaa", 1},
+		{copyright, "<COPYRIGHT>aaaa</COPYRIGHT>", "aaaa", 1},
+		{copyright, "<COPYRIGHT >aaaa</COPYRIGHT>", "aaaa", 1},
+		{copyright, "<COPYRIGHT >aaaa</COPYRIGHT >", "aaaa", 1},
+		{copyright, "<COPYRIGHT  >aaaa</COPYRIGHT  >", "aaaa", 1},
+		{copyright, "<copyright  >aaaa</COPYRIGHT  >", "aaaa", 1},
+		{copyright, "<copyright  >aaaa</copyright  >", "aaaa", 1},
+		{copyright, "<Copyright  >aaaa</Copyright  >", "aaaa", 1},
 
 		// Url (REF)
-		{urlreg, "<ref href=\"aaaa\"/>", "aaaa", 1},
-		{urlreg, "<ref href= \"aaaa\"/>", "aaaa", 1},
-		{urlreg, "<ref href = \"aaaa\"/>", "aaaa", 1},
-		{urlreg, "<ref href =\"aaaa\"/>", "aaaa", 1},
-		{urlreg, "<ref Href='aaaa'/>", "aaaa", 1},
-		{urlreg, "<ref href= 'aaaa'/>", "aaaa", 1},
-		{urlreg, "<ref href = 'aaaa'/>", "aaaa", 1},
-		{urlreg, "<ref href ='aaaa'/>", "aaaa", 1},
-		{urlreg, "<REF Href ='aaaa'/>", "aaaa", 1},
-		{urlreg, "<Ref href ='aaaa'/>", "aaaa", 1},
-		{urlreg, "<ReF href ='aaaa'/>", "aaaa", 1},
+		{href, "<ref href=\"aaaa\"/>", "aaaa", 1},
+		{href, "<ref href= \"aaaa\"/>", "aaaa", 1},
+		{href, "<ref href = \"aaaa\"/>", "aaaa", 1},
+		{href, "<ref href =\"aaaa\"/>", "aaaa", 1},
+		{href, "<ref Href='aaaa'/>", "aaaa", 1},
+		{href, "<ref href= 'aaaa'/>", "aaaa", 1},
+		{href, "<ref href = 'aaaa'/>", "aaaa", 1},
+		{href, "<ref href ='aaaa'/>", "aaaa", 1},
+		{href, "<REF Href ='aaaa'/>", "aaaa", 1},
+		{href, "<Ref href ='aaaa'/>", "aaaa", 1},
+		{href, "<ReF href ='aaaa'/>", "aaaa", 1},
 		// Closed REF
-		{urlreg, "<ref href=\"aaaa\"></ref>", "aaaa", 1},
-		{urlreg, "<ref href= \"aaaa\"></ref>", "aaaa", 1},
-		{urlreg, "<ref href = \"aaaa\"></ref>", "aaaa", 1},
-		{urlreg, "<ref href =\"aaaa\"></ref>", "aaaa", 1},
-		{urlreg, "<ref href='aaaa'></ref>", "aaaa", 1},
-		{urlreg, "<ref href= 'aaaa'></ref>", "aaaa", 1},
-		{urlreg, "<ref Href = 'aaaa'></ref>", "aaaa", 1},
-		{urlreg, "<ref href ='aaaa'></ref>", "aaaa", 1},
-		{urlreg, "<REF href ='aaaa'></ref>", "aaaa", 1},
-		{urlreg, "<Ref href ='aaaa'></ref >", "aaaa", 1},
-		{urlreg, "<ReF Href ='aaaa'></ref >", "aaaa", 1},
+		{href, "<ref href=\"aaaa\"></ref>", "aaaa", 1},
+		{href, "<ref href= \"aaaa\"></ref>", "aaaa", 1},
+		{href, "<ref href = \"aaaa\"></ref>", "aaaa", 1},
+		{href, "<ref href =\"aaaa\"></ref>", "aaaa", 1},
+		{href, "<ref href='aaaa'></ref>", "aaaa", 1},
+		{href, "<ref href= 'aaaa'></ref>", "aaaa", 1},
+		{href, "<ref Href = 'aaaa'></ref>", "aaaa", 1},
+		{href, "<ref href ='aaaa'></ref>", "aaaa", 1},
+		{href, "<REF href ='aaaa'></ref>", "aaaa", 1},
+		{href, "<Ref href ='aaaa'></ref >", "aaaa", 1},
+		{href, "<ReF Href ='aaaa'></ref >", "aaaa", 1},
 
 		// Base
-		{basereg, "<BASE href=\"aaaa\"/>", "aaaa", 1},
-		{basereg, "<BasE href= \"aaaa\"/>", "aaaa", 1},
-		{basereg, "<base Href = \"aaaa\" />", "aaaa", 1},
-		{basereg, "<Base href =\"aaaa\"  />", "aaaa", 1},
+		{base, "<BASE href=\"aaaa\"/>", "aaaa", 1},
+		{base, "<BasE href= \"aaaa\"/>", "aaaa", 1},
+		{base, "<base Href = \"aaaa\" />", "aaaa", 1},
+		{base, "<Base href =\"aaaa\"  />", "aaaa", 1},
 
-		{basereg, "<BASE href='aaaa'/>", "aaaa", 1},
-		{basereg, "<BasE Href= 'aaaa'/>", "aaaa", 1},
-		{basereg, "<base href = 'aaaa' />", "aaaa", 1},
-		{basereg, "<Base href ='aaaa'  />", "aaaa", 1},
+		{base, "<BASE href='aaaa'/>", "aaaa", 1},
+		{base, "<BasE Href= 'aaaa'/>", "aaaa", 1},
+		{base, "<base href = 'aaaa' />", "aaaa", 1},
+		{base, "<Base href ='aaaa'  />", "aaaa", 1},
 
 		// Closed BASE
-		{basereg, "<BASE href=\"aaaa\"></BASE>", "aaaa", 1},
-		{basereg, "<BasE Href= \"aaaa\" ></Base>", "aaaa", 1},
-		{basereg, "<base href = \"aaaa\" ></BASE>", "aaaa", 1},
-		{basereg, "<Base href =\"aaaa\" ></basE>", "aaaa", 1},
+		{base, "<BASE href=\"aaaa\"></BASE>", "aaaa", 1},
+		{base, "<BasE Href= \"aaaa\" ></Base>", "aaaa", 1},
+		{base, "<base href = \"aaaa\" ></BASE>", "aaaa", 1},
+		{base, "<Base href =\"aaaa\" ></basE>", "aaaa", 1},
 
-		{basereg, "<BASE href='aaaa'></BASE>", "aaaa", 1},
-		{basereg, "<BasE href= 'aaaa'></BasE>", "aaaa", 1},
-		{basereg, "<base href = 'aaaa'></baSe>", "aaaa", 1},
-		{basereg, "<Base Href ='aaaa'> </base>", "aaaa", 1},
+		{base, "<BASE href='aaaa'></BASE>", "aaaa", 1},
+		{base, "<BasE href= 'aaaa'></BasE>", "aaaa", 1},
+		{base, "<base href = 'aaaa'></baSe>", "aaaa", 1},
+		{base, "<Base Href ='aaaa'> </base>", "aaaa", 1},
 
 		// MoreInfo
-		{morereg, "<MOREINFO href=\"aaaa\" />", "aaaa", 1},
-		{morereg, "<MoreinfO href =\"aaaa\" />", "aaaa", 1},
-		{morereg, "<moreinfo Href = \"aaaa\"/>", "aaaa", 1},
-		{morereg, "<MOREINFO href= \"aaaa\"  />", "aaaa", 1},
+		{moreInfo, "<MOREINFO href=\"aaaa\" />", "aaaa", 1},
+		{moreInfo, "<MoreinfO href =\"aaaa\" />", "aaaa", 1},
+		{moreInfo, "<moreinfo Href = \"aaaa\"/>", "aaaa", 1},
+		{moreInfo, "<MOREINFO href= \"aaaa\"  />", "aaaa", 1},
 
 		// Closed MoreInfo
-		{morereg, "<MOREINFO href=\"aaaa\" > </MOREINFO>", "aaaa", 1},
-		{morereg, "<MOREINFO href =\"aaaa\"></MOREINFO>", "aaaa", 1},
-		{morereg, "<moreinfo href = \"aaaa\" ></moreinfo>", "aaaa", 1},
-		{morereg, "<MOREINFO href= \"aaaa\" ></MoreinfO>", "aaaa", 1},
+		{moreInfo, "<MOREINFO href=\"aaaa\" > </MOREINFO>", "aaaa", 1},
+		{moreInfo, "<MOREINFO href =\"aaaa\"></MOREINFO>", "aaaa", 1},
+		{moreInfo, "<moreinfo href = \"aaaa\" ></moreinfo>", "aaaa", 1},
+		{moreInfo, "<MOREINFO href= \"aaaa\" ></MoreinfO>", "aaaa", 1},
 	}
 
-	for _, rule := range testRuls {
+	for _, test := range tests {
 
-		values := rule.reg.FindStringSubmatch(rule.line)
-
-		expectedMatches := rule.matches + 1
-		matchesFound := len(values)
+		parser := NewAsxParser([]byte("<asx version=\"3.0\"><entry>" + test.line + "</entry></asx>"))
+		parser.Parse()
 
-		if expectedMatches != matchesFound {
-			t.Fatalf("Expected matches %d == %d (%s)", expectedMatches, matchesFound, rule.line)
+		items := parser.Document.Items
+		if len(items) != test.matches || items[0].Entry == nil {
+			t.Fatalf("Expected %d entry got %d (%s)", test.matches, len(items), test.line)
 		}
 
-		if rule.expected != values[1] {
-			t.Fatalf("Expected regexp to return '%s' but got '%s' for line '%s'", rule.expected, values[1], rule.line)
+		if value := test.getter(items[0].Entry); test.expected != value {
+			t.Fatalf("Expected parser to return '%s' but got '%s' for line '%s'", test.expected, value, test.line)
 		}
-
 	}
 }
 
@@ -232,51 +211,61 @@ func testAsxFile(filePath string, rulez map[int][]plTestStruct, t *testing.T) {
 	// Where X is the entry number and Y is stream number
 	keys := make(map[string]bool, 10)
 
-	// Go over extracted streams and check if data in them matches
-	// the tests
-	for _, stream := range parser.Streams {
+	var urlCount int
 
-		// First we get entry and stream number from the REF URL
-		// We do that to know from which ENTRY this stream comes from
-		// and which stream are we testing. In the test files we this data
-		// in the stream URL, here we just extract it
-		entryNumb, streamNumber, key := getStreamNumbers(stream.Url, t)
-		keys[key] = true
+	// Go over extracted streams and their fallbacks and check if data
+	// in them matches the tests
+	for _, stream := range parser.Streams {
+		for i, url := range append([]string{stream.Url}, stream.Fallbacks...) {
+
+			// First we get entry and stream number from the REF URL
+			// We do that to know from which ENTRY this stream comes from
+			// and which stream are we testing. In the test files we this data
+			// in the stream URL, here we just extract it
+			entryNumb, streamNumber, key := getStreamNumbers(url, t)
+			keys[key] = true
+			urlCount++
+
+			// REF elements of the entry are the stream and its fallbacks in order
+			if streamNumber != i+1 {
+				t.Fatalf("Expected REF %s to be URL number %d of the stream", url, streamNumber)
+			}
 
-		// t.Logf("en:%d, sn:%d", entryNumb, streamNumber)
+			// t.Logf("en:%d, sn:%d", entryNumb, streamNumber)
 
-		if entry, ok = rulez[entryNumb]; !ok {
-			t.Fatalf("Entry with index %d was not expected", entryNumb)
-		}
+			if entry, ok = rulez[entryNumb]; !ok {
+				t.Fatalf("Entry with index %d was not expected", entryNumb)
+			}
 
-		streamExpected := entry[streamNumber]
+			streamExpected := entry[streamNumber]
 
-		if streamExpected.Title != stream.Title {
-			t.Fatalf("Expected stream (%s:%d:%d) Title '%s' == '%s'", filePath, entryNumb, streamNumber, streamExpected.Title, stream.Title)
-		}
+			if streamExpected.Title != stream.Title {
+				t.Fatalf("Expected stream (%s:%d:%d) Title '%s' == '%s'", filePath, entryNumb, streamNumber, streamExpected.Title, stream.Title)
+			}
 
-		if streamExpected.Description != stream.Description {
-			t.Fatalf("Expected stream (%s:%d:%d) Description '%s' == '%s'", filePath, entryNumb, streamNumber, streamExpected.Description, stream.Description)
-		}
+			if streamExpected.Description != stream.Description {
+				t.Fatalf("Expected stream (%s:%d:%d) Description '%s' == '%s'", filePath, entryNumb, streamNumber, streamExpected.Description, stream.Description)
+			}
 
-		if streamExpected.Logo != stream.Logo {
-			t.Fatalf("Expected stream (%s:%d:%d) Logo '%s' == '%s'", filePath, entryNumb, streamNumber, streamExpected.Logo, stream.Logo)
-		}
+			if streamExpected.Logo != stream.Logo {
+				t.Fatalf("Expected stream (%s:%d:%d) Logo '%s' == '%s'", filePath, entryNumb, streamNumber, streamExpected.Logo, stream.Logo)
+			}
 
-		if streamExpected.Author != stream.Author {
-			t.Fatalf("Expected stream (%s:%d:%d) Author '%s' == '%s'", filePath, entryNumb, streamNumber, streamExpected.Author, stream.Author)
-		}
+			if streamExpected.Author != stream.Author {
+				t.Fatalf("Expected stream (%s:%d:%d) Author '%s' == '%s'", filePath, entryNumb, streamNumber, streamExpected.Author, stream.Author)
+			}
 
-		if streamExpected.Copyright != stream.Copyright {
-			t.Fatalf("Expected stream (%s:%d:%d) Copyright '%s' == '%s'", filePath, entryNumb, streamNumber, streamExpected.Copyright, stream.Copyright)
-		}
+			if streamExpected.Copyright != stream.Copyright {
+				t.Fatalf("Expected stream (%s:%d:%d) Copyright '%s' == '%s'", filePath, entryNumb, streamNumber, streamExpected.Copyright, stream.Copyright)
+			}
 
-		if streamExpected.MoreInfo != stream.MoreInfo {
-			t.Fatalf("Expected stream (%s:%d:%d) MoreInfo '%s' == '%s'", filePath, entryNumb, streamNumber, streamExpected.MoreInfo, stream.MoreInfo)
-		}
+			if streamExpected.MoreInfo != stream.MoreInfo {
+				t.Fatalf("Expected stream (%s:%d:%d) MoreInfo '%s' == '%s'", filePath, entryNumb, streamNumber, streamExpected.MoreInfo, stream.MoreInfo)
+			}
 
-		if streamExpected.Url != stream.Url {
-			t.Fatalf("Expected stream (%s:%d:%d) Url '%s' == '%s'", filePath, entryNumb, streamNumber, streamExpected.Url, stream.Url)
+			if streamExpected.Url != url {
+				t.Fatalf("Expected stream (%s:%d:%d) Url '%s' == '%s'", filePath, entryNumb, streamNumber, streamExpected.Url, url)
+			}
 		}
 	}
 
@@ -299,8 +288,12 @@ func testAsxFile(filePath string, rulez map[int][]plTestStruct, t *testing.T) {
 		}
 	}
 
-	if expectedStreamCount != len(parser.Streams) {
-		t.Fatalf("Expected %d streams got %d (%s)", expectedStreamCount, len(parser.Streams), filePath)
+	if expectedStreamCount != urlCount {
+		t.Fatalf("Expected %d stream URLs got %d (%s)", expectedStreamCount, urlCount, filePath)
+	}
+
+	if len(rulez) != len(parser.Streams) {
+		t.Fatalf("Expected %d streams got %d (%s)", len(rulez), len(parser.Streams), filePath)
 	}
 }
 
@@ -342,3 +335,244 @@ func BenchmarkAsxParsing2(b *testing.B) {
 		parser.Parse()
 	}
 }
+
+func TestAsxDocument(t *testing.T) {
+
+	parser := NewAsxParser(getPLFile("./testpls/asx3.asx"))
+	if err := parser.Parse(); err != nil {
+		t.Fatalf("Unexpected error %s", err)
+	}
+
+	doc := parser.Document
+
+	if doc.Version != "3.0" || doc.Title != "Radio & Friends" || doc.Base != "http://media.ex.com/radio" {
+		t.Fatalf("Unexpected document header %+v", doc.AsxMeta)
+	}
+
+	if doc.Banner == nil || doc.Banner.Href != "http://ex.com/banner.gif" || doc.Banner.Abstract != "Banner text" || doc.Banner.MoreInfo != "http://ex.com/banner" {
+		t.Fatalf("Unexpected banner %+v", doc.Banner)
+	}
+
+	if len(doc.Items) != 2 || doc.Items[0].Event == nil || doc.Items[1].Repeat == nil {
+		t.Fatalf("Expected EVENT and REPEAT items got %v", doc.Items)
+	}
+
+	event := doc.Items[0].Event
+	if event.Name != "intro" || event.WhenDone != "RESUME" || len(event.Items) != 1 {
+		t.Fatalf("Unexpected event %+v", event)
+	}
+
+	intro := event.Items[0].Entry
+	if intro.ClientSkip != "no" || intro.Duration != 30500*time.Millisecond || intro.StartTime != 5*time.Second {
+		t.Fatalf("Unexpected entry %+v", intro)
+	}
+
+	repeat := doc.Items[1].Repeat
+	if repeat.Count != 2 || len(repeat.Items) != 2 || repeat.Items[1].EntryRef == nil {
+		t.Fatalf("Unexpected repeat %+v", repeat)
+	}
+
+	if ref := repeat.Items[1].EntryRef; ref.Href != "http://ex.com/more.asx" || ref.ClientBind != "no" {
+		t.Fatalf("Unexpected entry ref %+v", ref)
+	}
+
+	var expected = []struct {
+		index int
+		title string
+		url   string
+	}{
+		{1, "Intro", "http://media.ex.com/radio/intro.wma?a=1&b=2"},
+		{2, "News & Weather", "mms://live.ex.com/news"},
+	}
+
+	if len(parser.Streams) != len(expected) {
+		t.Fatalf("Expected %d streams got %d", len(expected), len(parser.Streams))
+	}
+
+	for i, e := range expected {
+		s := parser.Streams[i]
+		if s.Index != e.index || s.Title != e.title || s.Url != e.url {
+			t.Fatalf("Expected stream %d %s %s got %d %s %s", e.index, e.title, e.url, s.Index, s.Title, s.Url)
+		}
+	}
+
+	if fallbacks := parser.Streams[1].Fallbacks; len(fallbacks) != 1 || fallbacks[0] != "http://live.ex.com/news" {
+		t.Fatalf("Expected REF fallback http://live.ex.com/news got %v", fallbacks)
+	}
+
+	if parser.Streams[0].Duration != 30500*time.Millisecond {
+		t.Fatalf("Expected stream duration 30.5s got %s", parser.Streams[0].Duration)
+	}
+
+	if diags := parser.GetDiagnostics(); len(diags) != 0 {
+		t.Fatalf("Expected no diagnostics got %v", diags)
+	}
+}
+
+func TestAsxLatin1(t *testing.T) {
+
+	// Transcoded content is longer than raw one
+	raw := "<?xml version=\"1.0\" encoding=\"iso-8859-1\"?><asx version=\"3.0\"><title>" +
+		strings.Repeat("\xe9", 200) + "</title><entry><ref href=\"http://ex.com/a\"/></entry></asx>"
+
+	parser := NewAsxParser([]byte(raw))
+	if err := parser.Parse(); err != nil {
+		t.Fatalf("Unexpected error %s", err)
+	}
+
+	if parser.Title != strings.Repeat("é", 200) {
+		t.Fatalf("Expected transcoded title got %s", parser.Title)
+	}
+
+	if len(parser.Streams) != 1 || parser.Streams[0].Url != "http://ex.com/a" {
+		t.Fatalf("Expected stream http://ex.com/a got %v", parser.Streams)
+	}
+}
+
+func TestAsxTime(t *testing.T) {
+
+	var tests = []struct {
+		value    string
+		expected time.Duration
+	}{
+		{"01:02:03", time.Hour + 2*time.Minute + 3*time.Second},
+		{"02:03.25", 2*time.Minute + 3250*time.Millisecond},
+		{"30", 30 * time.Second},
+		{"", 0},
+		{"aa:bb", 0},
+		{"1:2:3:4", 0},
+	}
+
+	for _, test := range tests {
+		if d := parseAsxTime(test.value); d != test.expected {
+			t.Fatalf("Expected %s got %s for %s", test.expected, d, test.value)
+		}
+	}
+}
+
+func TestAsxParams(t *testing.T) {
+
+	parser := NewAsxParser(getPLFile("./testpls/asx1.asx"))
+	if err := parser.Parse(); err != nil {
+		t.Fatalf("Unexpected error %s", err)
+	}
+
+	s := parser.Streams[0]
+	if s.Genre != "E1G" || s.Location != "E1L" || s.Homepage != "http://E1.hp.ex.com" || s.HTMLView != "http://E1.ex.com" {
+		t.Fatalf("Unexpected well known params %+v", s)
+	}
+
+	if len(s.Attributes) != 4 || s.Attributes["Genre"] != "E1G" || s.Attributes["HTMLView"] != "http://E1.ex.com" {
+		t.Fatalf("Unexpected attributes %v", s.Attributes)
+	}
+
+	raw := `<asx version="3.0">
+<param name="Genre" value="Jazz"/>
+<param name="Station" value="One"/>
+<entry><param name="genre" value="Blues"/><ref href="http://ex.com/a.mp3"/></entry>
+<entry><ref href="http://ex.com/b.mp3"/></entry>
+</asx>`
+
+	parser = NewAsxParser([]byte(raw))
+	if err := parser.Parse(); err != nil {
+		t.Fatalf("Unexpected error %s", err)
+	}
+
+	if s = parser.Streams[0]; s.Genre != "Blues" || s.Attributes["genre"] != "Blues" || len(s.Attributes) != 2 {
+		t.Fatalf("Expected entry param to override header param got %+v", s)
+	}
+
+	if s = parser.Streams[1]; s.Genre != "Jazz" || s.Attributes["Station"] != "One" {
+		t.Fatalf("Expected header params on every stream got %+v", s)
+	}
+}
+
+func TestAsxEntryRef(t *testing.T) {
+
+	dir, _ := filepath.Abs("./testpls")
+	base := "file://" + filepath.ToSlash(dir) + "/"
+
+	parser := NewAsxParser(getPLFile("./testpls/asx4.asx"))
+	parser.SetBaseUrl(base + "asx4.asx")
+	parser.SetAsxLoader(NewFileAsxLoader(""))
+
+	if err := parser.Parse(); err != nil {
+		t.Fatalf("Unexpected error %s", err)
+	}
+
+	var expected = []struct {
+		index  int
+		title  string
+		url    string
+		repeat int
+		chain  int
+	}{
+		{1, "First", "http://ex.com/first", 0, 0},
+		{2, "A", "http://ex.com/a", 3, 1},
+		{3, "B", "http://ex.com/b", -1, 1},
+		{4, "Last", "http://ex.com/last", 0, 0},
+	}
+
+	if len(parser.Streams) != len(expected) {
+		t.Fatalf("Expected %d streams got %d", len(expected), len(parser.Streams))
+	}
+
+	for i, e := range expected {
+		s := parser.Streams[i]
+		if s.Index != e.index || s.Title != e.title || s.Url != e.url || s.Repeat != e.repeat || len(s.Chain) != e.chain {
+			t.Fatalf("Expected stream %d %s %s %d got %d %s %s %d", e.index, e.title, e.url, e.repeat, s.Index, s.Title, s.Url, s.Repeat)
+		}
+	}
+
+	if chain := parser.Streams[1].Chain; chain[0] != base+"asx5.asx" {
+		t.Fatalf("Expected chain %s got %v", base+"asx5.asx", chain)
+	}
+
+	ref := parser.Document.Items[1].Repeat.Items[0].EntryRef
+	if ref.Url != base+"asx5.asx" || ref.Document == nil || len(ref.Document.Items) != 3 {
+		t.Fatalf("Expected referenced document got %+v", ref)
+	}
+
+	// The referenced playlist points back to the wrapper
+	if cycle := ref.Document.Items[1].EntryRef; cycle.Document != nil {
+		t.Fatalf("Expected cycle not to be followed got %+v", cycle)
+	}
+
+	diags := parser.GetDiagnostics()
+	if len(diags) != 1 || diags[0].Rule != RULE_ASX_ENTRYREF_FAILED || diags[0].Line != 10 {
+		t.Fatalf("Expected missing ENTRYREF diagnostic got %v", diags)
+	}
+
+	// ENTRYREF elements are not followed with no loader
+	parser = NewAsxParser(getPLFile("./testpls/asx4.asx"))
+	parser.Parse()
+
+	if len(parser.Streams) != 2 || parser.Streams[1].Index != 2 || len(parser.GetDiagnostics()) != 0 {
+		t.Fatalf("Expected ENTRYREF elements to be skipped got %v", parser.Streams)
+	}
+}
+
+func TestAsxEntryRefCycle(t *testing.T) {
+
+	dir, _ := filepath.Abs("./testpls")
+
+	parser := NewAsxParser(getPLFile("./testpls/asx5.asx"))
+	parser.SetBaseUrl("file://" + filepath.ToSlash(dir) + "/asx5.asx")
+	parser.SetAsxLoader(NewFileAsxLoader(""))
+	parser.Parse()
+
+	diags := parser.GetDiagnostics()
+	if len(diags) != 0 {
+		t.Fatalf("Expected no diagnostics got %v", diags)
+	}
+
+	ref := parser.Document.Items[1].EntryRef
+	if ref.Document == nil || len(parser.Streams) != 4 {
+		t.Fatalf("Expected wrapper to be loaded once got %d streams", len(parser.Streams))
+	}
+
+	cycle := ref.Document.Items[1].Repeat.Items[0].EntryRef
+	if cycle.Document != nil {
+		t.Fatalf("Expected cycle not to be followed got %+v", cycle)
+	}
+}
@@ -0,0 +1,308 @@
+// Part of the Go playlist parser package
+//
+// Copyright 2013 Rafal Zajac rzajac<at>gmail<dot>com. All rights reserved.
+// http://github.com/rzajac/plparser
+//
+// Licensed under the MIT license
+
+package plparser
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"io/ioutil"
+	"mime"
+	"regexp"
+	"strings"
+	"unicode/utf16"
+	"unicode/utf8"
+)
+
+// Charsets playlists can be transcoded from.
+const (
+	CHARSET_UTF8    = "utf-8"
+	CHARSET_UTF16LE = "utf-16le"
+	CHARSET_UTF16BE = "utf-16be"
+	CHARSET_WIN1250 = "windows-1250"
+	CHARSET_WIN1251 = "windows-1251"
+	CHARSET_WIN1252 = "windows-1252"
+	CHARSET_LATIN1  = "iso-8859-1"
+	CHARSET_LATIN2  = "iso-8859-2"
+	CHARSET_LATIN9  = "iso-8859-15"
+	CHARSET_DEFAULT = CHARSET_WIN1252 // Used when no other charset fits
+)
+
+// UTF-16 byte order marks.
+const (
+	utf16leBOM = "\xff\xfe"
+	utf16beBOM = "\xfe\xff"
+)
+
+// charsetSniffSize is the number of bytes checked for UTF-16 with no BOM.
+const charsetSniffSize = 1024
+
+// ErrUnknownCharset is returned when transcoding from not supported charset.
+var ErrUnknownCharset = errors.New("Unknown charset.")
+
+// charsetAliases maps charset labels to supported charset names.
+var charsetAliases = map[string]string{
+	"utf8":        CHARSET_UTF8,
+	"unicode":     CHARSET_UTF16LE,
+	"utf-16":      CHARSET_UTF16LE,
+	"cp1250":      CHARSET_WIN1250,
+	"x-cp1250":    CHARSET_WIN1250,
+	"cp1251":      CHARSET_WIN1251,
+	"x-cp1251":    CHARSET_WIN1251,
+	"cp1252":      CHARSET_WIN1252,
+	"x-cp1252":    CHARSET_WIN1252,
+	"us-ascii":    CHARSET_WIN1252,
+	"ascii":       CHARSET_WIN1252,
+	"latin1":      CHARSET_LATIN1,
+	"l1":          CHARSET_LATIN1,
+	"iso8859-1":   CHARSET_LATIN1,
+	"iso_8859-1":  CHARSET_LATIN1,
+	"latin2":      CHARSET_LATIN2,
+	"l2":          CHARSET_LATIN2,
+	"iso8859-2":   CHARSET_LATIN2,
+	"iso_8859-2":  CHARSET_LATIN2,
+	"latin9":      CHARSET_LATIN9,
+	"iso8859-15":  CHARSET_LATIN9,
+	"iso_8859-15": CHARSET_LATIN9,
+}
+
+// xmlEncodingRegExp regular expression to find encoding in XML declaration.
+var xmlEncodingRegExp = regexp.MustCompile(`(?i)^(\s*<\?xml[^>]*?\sencoding\s*=\s*["'])([^"']*)(["'])`)
+
+// Bytes of Polish letters specific to windows-1250 and iso-8859-2.
+// Other letters with diacritics are at the same positions in both.
+var (
+	win1250Bytes = []byte{0x8c, 0x8f, 0x9c, 0x9f, 0xa5, 0xb9}
+	latin2Bytes  = []byte{0xa1, 0xa6, 0xac, 0xb1, 0xb6, 0xbc}
+)
+
+// NormalizeCharset returns supported charset name for the charset label.
+// Returns empty string if charset is not supported.
+func NormalizeCharset(label string) string {
+
+	label = strings.ToLower(strings.TrimSpace(label))
+
+	if name, ok := charsetAliases[label]; ok {
+		return name
+	}
+
+	if _, ok := charsetTables[label]; ok {
+		return label
+	}
+
+	switch label {
+	case CHARSET_UTF8, CHARSET_UTF16LE, CHARSET_UTF16BE:
+		return label
+	}
+
+	return ""
+}
+
+// DetectCharset detects charset of playlist raw content. It uses in order
+// the byte order mark, charset parameter of HTTP content type, XML
+// encoding declaration and heuristics. Not supported charsets are ignored.
+func DetectCharset(raw []byte, contentType string) string {
+
+	switch {
+	case bytes.HasPrefix(raw, []byte(utf8BOM)):
+		return CHARSET_UTF8
+	case bytes.HasPrefix(raw, []byte(utf16leBOM)):
+		return CHARSET_UTF16LE
+	case bytes.HasPrefix(raw, []byte(utf16beBOM)):
+		return CHARSET_UTF16BE
+	}
+
+	if contentType != "" {
+		if _, params, err := mime.ParseMediaType(contentType); err == nil {
+			if charset := NormalizeCharset(params["charset"]); charset != "" {
+				return charset
+			}
+		}
+	}
+
+	if charset := detectUtf16(raw); charset != "" {
+		return charset
+	}
+
+	if values := xmlEncodingRegExp.FindSubmatch(raw); values != nil {
+		if charset := NormalizeCharset(string(values[2])); charset != "" {
+			return charset
+		}
+	}
+
+	return guessCharset(raw)
+}
+
+// detectUtf16 detects UTF-16 with no byte order mark by looking for
+// zero bytes in every other byte of the beginning of the content.
+func detectUtf16(raw []byte) string {
+
+	if len(raw) > charsetSniffSize {
+		raw = raw[:charsetSniffSize]
+	}
+
+	if len(raw) < 4 {
+		return ""
+	}
+
+	var even, odd int
+	for i, b := range raw {
+		if b != 0 {
+			continue
+		}
+		if i%2 == 0 {
+			even++
+		} else {
+			odd++
+		}
+	}
+
+	half := len(raw) / 2
+
+	switch {
+	case odd > half*3/4 && even == 0:
+		return CHARSET_UTF16LE
+	case even > half*3/4 && odd == 0:
+		return CHARSET_UTF16BE
+	}
+
+	return ""
+}
+
+// guessCharset guesses charset of content which is not declared.
+// Valid UTF-8 is assumed to be UTF-8. Otherwise content with Polish
+// letters specific to windows-1250 or iso-8859-2 is assumed to use
+// them and anything else falls back to CHARSET_DEFAULT.
+func guessCharset(raw []byte) string {
+
+	if utf8.Valid(raw) {
+		return CHARSET_UTF8
+	}
+
+	var win1250, latin2 int
+	for _, b := range raw {
+		switch {
+		case bytes.IndexByte(win1250Bytes, b) != -1:
+			win1250++
+		case bytes.IndexByte(latin2Bytes, b) != -1:
+			latin2++
+		}
+	}
+
+	switch {
+	case win1250 > 0 && win1250 >= latin2:
+		return CHARSET_WIN1250
+	case latin2 > 0:
+		return CHARSET_LATIN2
+	}
+
+	return CHARSET_DEFAULT
+}
+
+// ToUtf8 transcodes content from the charset to UTF-8. Byte order marks
+// are removed. Returns ErrUnknownCharset if charset is not supported.
+func ToUtf8(raw []byte, charset string) ([]byte, error) {
+
+	switch charset = NormalizeCharset(charset); charset {
+	case CHARSET_UTF8:
+		return bytes.TrimPrefix(raw, []byte(utf8BOM)), nil
+	case CHARSET_UTF16LE:
+		return utf16ToUtf8(bytes.TrimPrefix(raw, []byte(utf16leBOM)), false), nil
+	case CHARSET_UTF16BE:
+		return utf16ToUtf8(bytes.TrimPrefix(raw, []byte(utf16beBOM)), true), nil
+	}
+
+	table, ok := charsetTables[charset]
+	if !ok {
+		return nil, ErrUnknownCharset
+	}
+
+	buf := bytes.NewBuffer(make([]byte, 0, len(raw)+len(raw)/4))
+	for _, b := range raw {
+		if b < 0x80 {
+			buf.WriteByte(b)
+		} else {
+			buf.WriteRune(table[b-0x80])
+		}
+	}
+
+	return buf.Bytes(), nil
+}
+
+// utf16ToUtf8 transcodes UTF-16 content to UTF-8.
+func utf16ToUtf8(raw []byte, bigEndian bool) []byte {
+
+	units := make([]uint16, 0, len(raw)/2)
+	for i := 0; i+1 < len(raw); i += 2 {
+		if bigEndian {
+			units = append(units, uint16(raw[i])<<8|uint16(raw[i+1]))
+		} else {
+			units = append(units, uint16(raw[i+1])<<8|uint16(raw[i]))
+		}
+	}
+
+	buf := bytes.NewBuffer(make([]byte, 0, len(units)))
+	for _, r := range utf16.Decode(units) {
+		buf.WriteRune(r)
+	}
+
+	return buf.Bytes()
+}
+
+// setXmlEncoding replaces encoding in XML declaration of the content if
+// it's a different charset. Content without XML declaration or encoding
+// is returned unchanged.
+func setXmlEncoding(raw []byte, charset string) []byte {
+
+	values := xmlEncodingRegExp.FindSubmatch(raw)
+	if values == nil || NormalizeCharset(string(values[2])) == charset {
+		return raw
+	}
+
+	return xmlEncodingRegExp.ReplaceAll(raw, []byte("${1}"+charset+"${3}"))
+}
+
+// xmlToUtf8 transcodes XML content declaring other encoding than UTF-8
+// and changes the declaration to UTF-8. Content with no declaration or
+// declaring not supported encoding is returned unchanged.
+func xmlToUtf8(raw []byte) []byte {
+
+	values := xmlEncodingRegExp.FindSubmatch(raw)
+	if values == nil {
+		return raw
+	}
+
+	charset := NormalizeCharset(string(values[2]))
+	if charset == "" || charset == CHARSET_UTF8 {
+		return raw
+	}
+
+	utf, err := ToUtf8(raw, charset)
+	if err != nil {
+		return raw
+	}
+
+	return setXmlEncoding(utf, CHARSET_UTF8)
+}
+
+// xmlCharsetReader transcodes XML documents declaring other encoding
+// than UTF-8. It's used as encoding/xml Decoder.CharsetReader.
+func xmlCharsetReader(charset string, input io.Reader) (io.Reader, error) {
+
+	raw, err := ioutil.ReadAll(input)
+	if err != nil {
+		return nil, err
+	}
+
+	raw, err = ToUtf8(raw, charset)
+	if err != nil {
+		return nil, err
+	}
+
+	return bytes.NewReader(raw), nil
+}
@@ -0,0 +1,124 @@
+// Part of the Go playlist parser package
+//
+// Copyright 2013 Rafal Zajac rzajac<at>gmail<dot>com. All rights reserved.
+// http://github.com/rzajac/plparser
+//
+// Licensed under the MIT license
+
+package plparser
+
+import (
+	"testing"
+)
+
+func TestDetectCharset(t *testing.T) {
+
+	var tests = []struct {
+		name        string
+		raw         string
+		contentType string
+		expected    string
+	}{
+		{"utf-8", "#EXTM3U\n#EXTINF:-1,Zażółć\nhttp://ex.com/\n", "", CHARSET_UTF8},
+		{"utf-8 bom", "\xef\xbb\xbf#EXTM3U\n", "text/plain; charset=iso-8859-2", CHARSET_UTF8},
+		{"utf-16le bom", "\xff\xfe#\x00E\x00", "", CHARSET_UTF16LE},
+		{"utf-16be bom", "\xfe\xff\x00#\x00E", "", CHARSET_UTF16BE},
+		{"utf-16le no bom", "#\x00E\x00X\x00T\x00M\x003\x00U\x00", "", CHARSET_UTF16LE},
+		{"http charset", "#EXTM3U\n", "audio/x-mpegurl; charset=ISO-8859-2", CHARSET_LATIN2},
+		{"http charset alias", "#EXTM3U\n", "audio/x-mpegurl; charset=cp1250", CHARSET_WIN1250},
+		{"http charset unknown", "#EXTM3U\n", "audio/x-mpegurl; charset=koi8-r", CHARSET_UTF8},
+		{"xml encoding", "<?xml version=\"1.0\" encoding=\"windows-1251\"?>\n<asx>", "", CHARSET_WIN1251},
+		{"windows-1250", "Title1=\xb3\xf3d\x9f\n", "", CHARSET_WIN1250},
+		{"iso-8859-2", "Title1=\xb3\xf3d\xbc\n", "", CHARSET_LATIN2},
+		{"windows-1252", "Title1=M\xfcnchen \x96 Stra\xdfe\n", "", CHARSET_WIN1252},
+	}
+
+	for _, test := range tests {
+		if charset := DetectCharset([]byte(test.raw), test.contentType); charset != test.expected {
+			t.Fatalf("Expected charset %s got %s (%s)", test.expected, charset, test.name)
+		}
+	}
+}
+
+func TestToUtf8(t *testing.T) {
+
+	var tests = []struct {
+		raw      string
+		charset  string
+		expected string
+	}{
+		{"\xef\xbb\xbfZa\xc5\xbc\xc3\xb3\xc5\x82\xc4\x87", "utf-8", "Zażółć"},
+		{"\xff\xfeZ\x00a\x00|\x01\xf3\x00B\x01\x07\x01", "utf-16le", "Zażółć"},
+		{"\x00Z\x00a\x01|\x00\xf3\x01B\x01\x07", "UTF-16BE", "Zażółć"},
+		{"Za\xbf\xf3\xb3\xe6 g\xea\x9cl\xb9 ja\x9f\xf1", "windows-1250", "Zażółć gęślą jaźń"},
+		{"Za\xbf\xf3\xb3\xe6 g\xea\xb6l\xb1 ja\xbc\xf1", "latin2", "Zażółć gęślą jaźń"},
+		{"M\xfcnchen \x96 Stra\xdfe", "cp1252", "München – Straße"},
+		{"M\xfcnchen \xa4", "iso-8859-15", "München €"},
+		{"\xcc\xee\xf1\xea\xe2\xe0", "windows-1251", "Москва"},
+	}
+
+	for _, test := range tests {
+
+		utf, err := ToUtf8([]byte(test.raw), test.charset)
+		if err != nil {
+			t.Fatalf("Unexpected error %s (%s)", err, test.charset)
+		}
+
+		if string(utf) != test.expected {
+			t.Fatalf("Expected %s got %s (%s)", test.expected, utf, test.charset)
+		}
+	}
+
+	if _, err := ToUtf8([]byte("text"), "koi8-r"); err != ErrUnknownCharset {
+		t.Fatalf("Expected ErrUnknownCharset got %v", err)
+	}
+}
+
+func TestPlaylistCharset(t *testing.T) {
+
+	plr := new(PlaylistResp)
+	plr.Raw = getPLFile("./testpls/pls5.pls")
+
+	pl := NewPlaylist(plr)
+	if _, err := pl.Parse(); err != nil {
+		t.Fatalf("Unexpected error %s", err)
+	}
+
+	if pl.Charset != CHARSET_WIN1250 {
+		t.Fatalf("Expected charset %s got %s", CHARSET_WIN1250, pl.Charset)
+	}
+
+	if len(pl.Streams) != 1 || pl.Streams[0].Title != "Radio Łódź - Zażółć gęślą jaźń" {
+		t.Fatalf("Unexpected streams %v", pl.Streams)
+	}
+}
+
+func TestPlaylistCharsetXml(t *testing.T) {
+
+	plr := new(PlaylistResp)
+	plr.Raw = []byte("<?xml version=\"1.0\" encoding=\"ISO-8859-2\"?>\n" +
+		"<playlist version=\"1\" xmlns=\"http://xspf.org/ns/0/\"><trackList><track>" +
+		"<location>http://ex.com/a.ogg</location><title>\xa3\xf3d\xbc</title>" +
+		"</track></trackList></playlist>")
+
+	pl := NewPlaylist(plr)
+	if _, err := pl.Parse(); err != nil {
+		t.Fatalf("Unexpected error %s", err)
+	}
+
+	if pl.Charset != CHARSET_LATIN2 || len(pl.Streams) != 1 || pl.Streams[0].Title != "Łódź" {
+		t.Fatalf("Unexpected charset %s and streams %v", pl.Charset, pl.Streams)
+	}
+
+	// Parser used directly transcodes XML itself
+	xspf := NewXspfParser([]byte("<?xml version=\"1.0\" encoding=\"windows-1250\"?>\n" +
+		"<playlist version=\"1\" xmlns=\"http://xspf.org/ns/0/\"><title>\xa3\xf3d\x9f</title></playlist>"))
+
+	if err := xspf.Parse(); err != nil {
+		t.Fatalf("Unexpected error %s", err)
+	}
+
+	if xspf.Title != "Łódź" {
+		t.Fatalf("Expected title Łódź got %s", xspf.Title)
+	}
+}
@@ -0,0 +1,199 @@
+// Part of the Go playlist parser package
+//
+// Copyright 2013 Rafal Zajac rzajac<at>gmail<dot>com. All rights reserved.
+// http://github.com/rzajac/plparser
+//
+// Licensed under the MIT license
+
+// Command plparser detects, parses and converts playlists.
+//
+// Usage:
+//
+//	plparser detect [-timeout sec] [file|url|-]
+//	plparser parse [-timeout sec] [-format json|csv|table] [file|url|-]
+//	plparser convert [-timeout sec] -to pls|m3u|asx|xspf [file|url|-]
+//
+// When no source is given or the source is "-" the playlist is read from
+// the standard input.
+package main
+
+import (
+	"context"
+	"encoding/csv"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/rzajac/plparser"
+)
+
+// usage is printed when command line arguments are invalid.
+const usage = `Usage:
+  plparser detect [-timeout sec] [file|url|-]
+  plparser parse [-timeout sec] [-format json|csv|table] [file|url|-]
+  plparser convert [-timeout sec] -to pls|m3u|asx|xspf [file|url|-]
+`
+
+func main() {
+	os.Exit(run(os.Args[1:], os.Stdin, os.Stdout, os.Stderr))
+}
+
+// run runs the command and returns process exit code.
+func run(args []string, stdin io.Reader, stdout, stderr io.Writer) int {
+
+	if len(args) == 0 {
+		fmt.Fprint(stderr, usage)
+		return 2
+	}
+
+	fs := flag.NewFlagSet(args[0], flag.ContinueOnError)
+	fs.SetOutput(stderr)
+	timeout := fs.Int("timeout", 10, "timeout in seconds when fetching URL")
+	format := fs.String("format", "json", "output format: json, csv or table")
+	to := fs.String("to", "", "target playlist format: pls, m3u, asx or xspf")
+
+	if err := fs.Parse(args[1:]); err != nil {
+		return 2
+	}
+
+	if fs.NArg() > 1 {
+		fmt.Fprint(stderr, usage)
+		return 2
+	}
+
+	var err error
+
+	switch args[0] {
+	case "detect":
+		err = detect(fs.Arg(0), *timeout, stdin, stdout)
+	case "parse":
+		err = parse(fs.Arg(0), *timeout, *format, stdin, stdout)
+	case "convert":
+		err = convert(fs.Arg(0), *timeout, *to, stdin, stdout)
+	default:
+		fmt.Fprint(stderr, usage)
+		return 2
+	}
+
+	if err != nil {
+		fmt.Fprintln(stderr, "plparser:", err)
+		return 1
+	}
+
+	return 0
+}
+
+// detect prints detected playlist type.
+func detect(src string, timeout int, stdin io.Reader, stdout io.Writer) error {
+
+	pl, err := load(src, timeout, stdin)
+	if err != nil {
+		return err
+	}
+
+	fmt.Fprintln(stdout, pl.Type)
+
+	return nil
+}
+
+// parse prints playlist streams in given format.
+func parse(src string, timeout int, format string, stdin io.Reader, stdout io.Writer) error {
+
+	pl, err := load(src, timeout, stdin)
+	if err != nil {
+		return err
+	}
+
+	switch format {
+	case "json":
+		j, err := pl.StreamsAsJson()
+		if err != nil {
+			return err
+		}
+		fmt.Fprintln(stdout, j)
+
+	case "csv":
+		w := csv.NewWriter(stdout)
+		w.Write([]string{"index", "title", "url", "duration"})
+		for _, s := range pl.Streams {
+			w.Write([]string{strconv.Itoa(s.Index), s.Title, s.Url, strconv.FormatInt(int64(s.Duration.Seconds()), 10)})
+		}
+		w.Flush()
+		return w.Error()
+
+	case "table":
+		w := tabwriter.NewWriter(stdout, 0, 4, 2, ' ', 0)
+		fmt.Fprintln(w, "INDEX\tTITLE\tURL\tDURATION")
+		for _, s := range pl.Streams {
+			fmt.Fprintf(w, "%d\t%s\t%s\t%s\n", s.Index, s.Title, s.Url, s.Duration)
+		}
+		return w.Flush()
+
+	default:
+		return errors.New("unknown output format: " + format)
+	}
+
+	return nil
+}
+
+// convert writes playlist in a different format.
+func convert(src string, timeout int, to string, stdin io.Reader, stdout io.Writer) error {
+
+	if to == "" {
+		return errors.New("missing target format (-to)")
+	}
+
+	pl, err := load(src, timeout, stdin)
+	if err != nil {
+		return err
+	}
+
+	return pl.Write(stdout, to)
+}
+
+// load loads and parses playlist from file, URL or standard input.
+func load(src string, timeout int, stdin io.Reader) (*plparser.Playlist, error) {
+
+	var plr *plparser.PlaylistResp
+	var err error
+
+	switch {
+	case src == "" || src == "-":
+		pl, err := plparser.ParseReader(context.Background(), stdin, nil)
+		if err != nil {
+			return nil, err
+		}
+
+		return pl, nil
+
+	case strings.HasPrefix(src, "http://") || strings.HasPrefix(src, "https://"):
+		plr, err = plparser.NewPlaylistRespUrl(src, timeout)
+
+	default:
+		plr, err = plparser.NewPlaylistRespFile(src)
+	}
+
+	if err != nil {
+		return nil, err
+	}
+
+	if !plr.IsPotentialPlaylist() {
+		return nil, plparser.ErrNotPlaylist
+	}
+
+	pl := plparser.NewPlaylist(plr)
+	if _, err = pl.Parse(); err != nil {
+		return nil, err
+	}
+
+	if !pl.IsDetected() {
+		return nil, plparser.ErrUnsupportedFormat
+	}
+
+	return pl, nil
+}
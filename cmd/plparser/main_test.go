@@ -0,0 +1,54 @@
+// Part of the Go playlist parser package
+//
+// Copyright 2013 Rafal Zajac rzajac<at>gmail<dot>com. All rights reserved.
+// http://github.com/rzajac/plparser
+//
+// Licensed under the MIT license
+
+package main
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+)
+
+func TestRun(t *testing.T) {
+
+	var tests = []struct {
+		args     []string
+		stdin    string
+		code     int
+		contains string
+	}{
+		{[]string{"detect", "../../testpls/pls1.pls"}, "", 0, "pls\n"},
+		{[]string{"detect"}, "[Reference]\nRef1=http://live.example.com/\n", 0, "asf\n"},
+		{[]string{"detect", "-"}, "http://live.example.com/\n", 0, "m3u\n"},
+		{[]string{"parse", "../../testpls/m3u1.m3u"}, "", 0, `"url": "http://live2.example.com:2152/"`},
+		{[]string{"parse", "-format", "csv", "../../testpls/m3u1.m3u"}, "", 0, "2,,http://live2.example.com:2152/,0\n"},
+		{[]string{"parse", "-format", "table", "../../testpls/m3u1.m3u"}, "", 0, "http://live1.example.com:2151/"},
+		{[]string{"convert", "-to", "pls", "../../testpls/m3u1.m3u"}, "", 0, "File2=http://live2.example.com:2152/\n"},
+		{[]string{"detect"}, "Not a playlist", 1, ""},
+		{[]string{"parse", "-format", "bad", "../../testpls/m3u1.m3u"}, "", 1, ""},
+		{[]string{"convert", "../../testpls/m3u1.m3u"}, "", 1, ""},
+		{[]string{"convert", "-to", "bad", "../../testpls/m3u1.m3u"}, "", 1, ""},
+		{[]string{"detect", "../../testpls/does_not_exist.pls"}, "", 1, ""},
+		{[]string{"unknown"}, "", 2, ""},
+		{[]string{}, "", 2, ""},
+	}
+
+	for _, test := range tests {
+
+		var stdout, stderr bytes.Buffer
+
+		code := run(test.args, strings.NewReader(test.stdin), &stdout, &stderr)
+
+		if code != test.code {
+			t.Fatalf("Expected exit code %d got %d for %v (%s)", test.code, code, test.args, stderr.String())
+		}
+
+		if !strings.Contains(stdout.String(), test.contains) {
+			t.Fatalf("Expected output of %v to contain '%s' got:\n%s", test.args, test.contains, stdout.String())
+		}
+	}
+}
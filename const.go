@@ -9,8 +9,9 @@ package plparser
 
 // Where the playlist came from.
 const (
-	ORIGIN_URL  = "url"
-	ORIGIN_FILE = "file"
+	ORIGIN_URL    = "url"
+	ORIGIN_FILE   = "file"
+	ORIGIN_READER = "reader"
 )
 
 // Content types.
@@ -0,0 +1,198 @@
+// Part of the Go playlist parser package
+//
+// Copyright 2013 Rafal Zajac rzajac<at>gmail<dot>com. All rights reserved.
+// http://github.com/rzajac/plparser
+//
+// Licensed under the MIT license
+
+package plparser
+
+import (
+	"bufio"
+	"bytes"
+	"net/url"
+	"path"
+	"sort"
+	"strings"
+)
+
+// Confidence added by each kind of evidence found by Detector.
+const (
+	ConfidenceFirstLine   = 0.6  // The first not empty line matches the format
+	ConfidenceOtherLine   = 0.4  // One of the following lines matches the format
+	ConfidenceContentType = 0.25 // HTTP Content-Type is one of format MIME types
+	ConfidenceExtension   = 0.15 // URL or file extension is one of format extensions
+	ConfidencePlausible   = 0.35 // Content confirmed by content type or extension is plausible for the format
+)
+
+// DetectThreshold is the minimal confidence for a playlist type to be detected.
+const DetectThreshold = 0.5
+
+// DefaultDetectLines is the default number of not empty lines Detector scans.
+const DefaultDetectLines = 10
+
+// utf8BOM is the UTF-8 byte order mark.
+const utf8BOM = "\xef\xbb\xbf"
+
+// Candidate is a playlist format candidate returned by Detector.
+type Candidate struct {
+	Format     string   `json:"format"`
+	Confidence float64  `json:"confidence"`
+	Reasons    []string `json:"reasons"`
+}
+
+// Detector detects playlist formats using playlist content, HTTP content
+// type and the URL or file extension.
+type Detector struct {
+	// MaxLines is the number of not empty lines to scan.
+	// Zero means DefaultDetectLines.
+	MaxLines int
+}
+
+// NewDetector returns new detector.
+func NewDetector() *Detector {
+	d := new(Detector)
+	d.MaxLines = DefaultDetectLines
+	return d
+}
+
+// DetectFormat returns ranked format candidates for the playlist response
+// using the default detector.
+func DetectFormat(plr *PlaylistResp) []*Candidate {
+	return NewDetector().Detect(plr)
+}
+
+// Detect returns format candidates for the playlist response ordered by
+// confidence from the most to the least probable. Formats with no
+// evidence are not returned.
+func (d *Detector) Detect(plr *PlaylistResp) []*Candidate {
+
+	formats := Formats()
+	candidates := make(map[string]*Candidate, len(formats))
+
+	add := func(name string, confidence float64, reason string) {
+		c, ok := candidates[name]
+		if !ok {
+			c = &Candidate{Format: name}
+			candidates[name] = c
+		}
+
+		c.Confidence += confidence
+		if c.Confidence > 1 {
+			c.Confidence = 1
+		}
+		c.Reasons = append(c.Reasons, reason)
+	}
+
+	// Content
+	raw := bytes.TrimPrefix(plr.Raw, []byte(utf8BOM))
+	contentMatched := false
+
+	for idx, line := range d.lines(raw) {
+		header := strings.ToLower(line)
+
+		for _, f := range formats {
+
+			if _, ok := candidates[f.Name]; ok || f.Detect == nil {
+				continue
+			}
+
+			if !f.Detect(header, raw) {
+				continue
+			}
+
+			contentMatched = true
+
+			if idx == 0 {
+				add(f.Name, ConfidenceFirstLine, "first line")
+			} else {
+				add(f.Name, ConfidenceOtherLine, "content")
+			}
+		}
+	}
+
+	// Content type
+	if plr.ContentType != "" {
+		for _, f := range LookupMimeType(plr.ContentType) {
+			add(f.Name, ConfidenceContentType, "content type")
+		}
+	}
+
+	// Extension
+	if ext := urlExtension(plr.Url); ext != "" {
+		for _, f := range LookupExtension(ext) {
+			add(f.Name, ConfidenceExtension, "extension")
+		}
+	}
+
+	// Content no format recognizes may still be plausible
+	// for formats the content type or extension points to
+	if !contentMatched {
+		header := strings.ToLower(firstLine(d.lines(raw)))
+
+		for _, f := range formats {
+			if c, ok := candidates[f.Name]; ok && f.Plausible != nil && f.Plausible(header, raw) {
+				add(c.Format, ConfidencePlausible, "plausible content")
+			}
+		}
+	}
+
+	// Rank candidates keeping registration order for equal confidence
+	ranked := make([]*Candidate, 0, len(candidates))
+	for _, f := range formats {
+		if c, ok := candidates[f.Name]; ok {
+			ranked = append(ranked, c)
+		}
+	}
+
+	sort.SliceStable(ranked, func(i, j int) bool {
+		return ranked[i].Confidence > ranked[j].Confidence
+	})
+
+	return ranked
+}
+
+// firstLine returns the first of lines or empty string.
+func firstLine(lines []string) string {
+	if len(lines) == 0 {
+		return ""
+	}
+
+	return lines[0]
+}
+
+// lines returns first MaxLines not empty lines of raw.
+func (d *Detector) lines(raw []byte) []string {
+
+	maxLines := d.MaxLines
+	if maxLines <= 0 {
+		maxLines = DefaultDetectLines
+	}
+
+	lines := make([]string, 0, maxLines)
+	reader := bufio.NewReader(bytes.NewReader(raw))
+
+	for len(lines) < maxLines {
+		line, err := reader.ReadString('\n')
+
+		if line = fixString(line); line != "" {
+			lines = append(lines, line)
+		}
+
+		if err != nil {
+			break
+		}
+	}
+
+	return lines
+}
+
+// urlExtension returns lowercased extension of URL path or file path.
+func urlExtension(location string) string {
+
+	if u, err := url.Parse(location); err == nil && u.Path != "" {
+		location = u.Path
+	}
+
+	return strings.ToLower(path.Ext(location))
+}
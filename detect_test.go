@@ -0,0 +1,86 @@
+// Part of the Go playlist parser package
+//
+// Copyright 2013 Rafal Zajac rzajac<at>gmail<dot>com. All rights reserved.
+// http://github.com/rzajac/plparser
+//
+// Licensed under the MIT license
+
+package plparser
+
+import (
+	"math"
+	"testing"
+)
+
+func TestDetector(t *testing.T) {
+
+	var tests = []struct {
+		raw         string
+		url         string
+		contentType string
+		format      string
+		confidence  float64
+		candidates  int
+	}{
+		{"[playlist]\nFile1=http://ex.com/", "", "", "pls", ConfidenceFirstLine, 1},
+		{"[playlist]\nFile1=http://ex.com/", "http://ex.com/a.pls?x=1", "audio/x-scpls", "pls", 1, 1},
+		{"\xef\xbb\xbf#EXTM3U\nhttp://ex.com/", "", "", "m3u", ConfidenceFirstLine, 1},
+		{"#EXTM3U\n#EXT-X-VERSION:3\nhttp://ex.com/", "", "application/vnd.apple.mpegurl; charset=utf-8", "hls", ConfidenceFirstLine + ConfidenceContentType, 1},
+		{"<!-- comment -->\n[playlist]\nFile1=http://ex.com/", "", "", "pls", ConfidenceOtherLine, 1},
+		{"<ASX version=\"3.0\"></ASX>", "", "video/x-ms-asf", "asx", ConfidenceFirstLine + ConfidenceContentType, 2},
+		{"[Reference]\nRef1=http://ex.com/", "", "video/x-ms-asf", "asf", ConfidenceFirstLine + ConfidenceContentType, 2},
+		{"Not a playlist", "/path/to/file.m3u", "audio/x-mpegurl", "m3u", ConfidenceContentType + ConfidenceExtension, 1},
+		{"stream.mp3\n/live/aac", "http://ex.com/list.m3u", "audio/x-mpegurl", "m3u", ConfidenceContentType + ConfidenceExtension + ConfidencePlausible, 1},
+		{"Music\\a.mp3", "/path/to/file.m3u", "", "m3u", ConfidenceExtension + ConfidencePlausible, 1},
+		{"stream.mp3", "", "", "", 0, 0},
+		{"Not a playlist", "", "text/plain", "", 0, 0},
+	}
+
+	for _, test := range tests {
+
+		plr := new(PlaylistResp)
+		plr.Raw = []byte(test.raw)
+		plr.Url = test.url
+		plr.ContentType = test.contentType
+
+		candidates := DetectFormat(plr)
+
+		if len(candidates) != test.candidates {
+			t.Fatalf("Expected %d candidates got %d for '%s'", test.candidates, len(candidates), test.raw)
+		}
+
+		if test.candidates == 0 {
+			continue
+		}
+
+		if candidates[0].Format != test.format {
+			t.Fatalf("Expected format '%s' got '%s' for '%s'", test.format, candidates[0].Format, test.raw)
+		}
+
+		if math.Abs(candidates[0].Confidence-test.confidence) > 0.001 {
+			t.Fatalf("Expected confidence %.2f got %.2f for '%s'", test.confidence, candidates[0].Confidence, test.raw)
+		}
+	}
+}
+
+func TestDetectorThreshold(t *testing.T) {
+
+	// Content type and extension alone are not enough
+	plr := new(PlaylistResp)
+	plr.Raw = []byte("Not a playlist")
+	plr.Url = "http://ex.com/a.pls"
+	plr.ContentType = "audio/x-scpls"
+
+	if pl := NewPlaylist(plr); pl.detectType() {
+		t.Fatalf("Expected playlist not to be detected got '%s'", pl.Type)
+	}
+
+	// Content type helps content found after the first line
+	plr = new(PlaylistResp)
+	plr.Raw = []byte("garbage\n[playlist]\nFile1=http://ex.com/")
+	plr.ContentType = "audio/x-scpls"
+
+	if pl := NewPlaylist(plr); !pl.detectType() || pl.Type != "pls" {
+		t.Fatalf("Expected pls playlist got '%s'", pl.Type)
+	}
+}
@@ -0,0 +1,131 @@
+// Part of the Go playlist parser package
+//
+// Copyright 2013 Rafal Zajac rzajac<at>gmail<dot>com. All rights reserved.
+// http://github.com/rzajac/plparser
+//
+// Licensed under the MIT license
+
+package plparser
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+)
+
+// Severity is the severity of a parse diagnostic.
+type Severity int
+
+// Diagnostic severities.
+const (
+	SeverityInfo Severity = iota
+	SeverityWarning
+	SeverityError
+)
+
+// severityNames are names of severities.
+var severityNames = map[Severity]string{
+	SeverityInfo:    "info",
+	SeverityWarning: "warning",
+	SeverityError:   "error",
+}
+
+// String returns severity name.
+func (s Severity) String() string {
+	if name, ok := severityNames[s]; ok {
+		return name
+	}
+
+	return "unknown"
+}
+
+// MarshalJSON marshals severity as its name.
+func (s Severity) MarshalJSON() ([]byte, error) {
+	return json.Marshal(s.String())
+}
+
+// Diagnostic describes a problem found while parsing a playlist.
+type Diagnostic struct {
+	Line     int      `json:"line"`   // 1 based line number, 0 if unknown
+	Column   int      `json:"column"` // 1 based column number, 0 if unknown
+	Severity Severity `json:"severity"`
+	Rule     string   `json:"rule"` // One of RULE_* identifiers
+	Message  string   `json:"message"`
+	Text     string   `json:"text"` // The offending text
+}
+
+// String returns diagnostic in form of line:column: severity: message [rule] (text).
+func (d *Diagnostic) String() string {
+	return fmt.Sprintf("%d:%d: %s: %s [%s] (%s)", d.Line, d.Column, d.Severity, d.Message, d.Rule, d.Text)
+}
+
+// Diagnostics collects parse diagnostics and validation findings.
+// Parsers embed it to implement GetDiagnostics method of Playlister
+// interface and the Validator interface.
+type Diagnostics struct {
+	diagnostics []*Diagnostic
+	findings    []*Diagnostic
+}
+
+// AddDiagnostic adds new diagnostic.
+func (d *Diagnostics) AddDiagnostic(line, column int, severity Severity, rule, message, text string) {
+	d.diagnostics = append(d.diagnostics, newDiagnostic(line, column, severity, rule, message, text))
+}
+
+// AddFinding adds new validation finding. Findings are problems which
+// do not affect parsing and are reported only by Validate.
+func (d *Diagnostics) AddFinding(line, column int, severity Severity, rule, message, text string) {
+	d.findings = append(d.findings, newDiagnostic(line, column, severity, rule, message, text))
+}
+
+// GetDiagnostics gets list of diagnostics collected during parsing.
+func (d *Diagnostics) GetDiagnostics() []*Diagnostic {
+	return d.diagnostics
+}
+
+// Validate returns diagnostics and validation findings collected during
+// parsing ordered by position in the playlist.
+func (d *Diagnostics) Validate() []*Diagnostic {
+
+	all := make([]*Diagnostic, 0, len(d.diagnostics)+len(d.findings))
+	all = append(all, d.diagnostics...)
+	all = append(all, d.findings...)
+
+	sort.SliceStable(all, func(i, j int) bool {
+		if all[i].Line != all[j].Line {
+			return all[i].Line < all[j].Line
+		}
+		return all[i].Column < all[j].Column
+	})
+
+	return all
+}
+
+// newDiagnostic returns new diagnostic.
+func newDiagnostic(line, column int, severity Severity, rule, message, text string) *Diagnostic {
+	return &Diagnostic{
+		Line:     line,
+		Column:   column,
+		Severity: severity,
+		Rule:     rule,
+		Message:  message,
+		Text:     text,
+	}
+}
+
+// lineColumn returns 1 based line and column of the byte offset in text.
+func lineColumn(text string, offset int) (line, column int) {
+
+	line, column = 1, 1
+
+	for i := 0; i < offset && i < len(text); i++ {
+		if text[i] == '\n' {
+			line++
+			column = 1
+		} else {
+			column++
+		}
+	}
+
+	return
+}
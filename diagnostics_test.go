@@ -0,0 +1,113 @@
+// Part of the Go playlist parser package
+//
+// Copyright 2013 Rafal Zajac rzajac<at>gmail<dot>com. All rights reserved.
+// http://github.com/rzajac/plparser
+//
+// Licensed under the MIT license
+
+package plparser
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+)
+
+func TestParserDiagnostics(t *testing.T) {
+
+	var tests = []struct {
+		name     string
+		parser   Playlister
+		line     int
+		column   int
+		severity Severity
+		message  string
+	}{
+		{"pls missing =", NewPlsParser([]byte("[playlist]\nFile1=http://ex.com/\nFile3 http://ex.com/\n")), 3, 1, SeverityWarning, "Missing '='"},
+		{"pls duplicate", NewPlsParser([]byte("[playlist]\nFile1=http://ex.com/\nFile1=http://ex2.com/\n")), 3, 1, SeverityWarning, "Duplicate File1"},
+		{"pls not url", NewPlsParser([]byte("[playlist]\nFile1=stream.mp3\n")), 2, 1, SeverityWarning, "Not a URL"},
+		{"asf missing =", NewAsfParser([]byte("[Reference]\nRef1 http://ex.com/\n")), 2, 1, SeverityWarning, "Missing '='"},
+		{"asf duplicate", NewAsfParser([]byte("[Reference]\nRef1=http://ex.com/\nRef1=http://ex.com/\n")), 3, 1, SeverityWarning, "Duplicate Ref1"},
+		{"asx unclosed", NewAsxParser([]byte("<asx version=\"3.0\">\n<entry><ref href=\"http://ex.com/\"/></entry>\n  <entry><ref href=\"http://ex2.com/\"/>\n</asx>")), 3, 3, SeverityError, "Unclosed <entry>"},
+		{"asx not url", NewAsxParser([]byte("<asx version=\"3.0\">\n<entry>\n<ref href=\"stream.mp3\"/></entry></asx>")), 3, 1, SeverityWarning, "Not a URL"},
+		{"m3u not url", NewM3uParser([]byte("#EXTM3U\nhttp://ex.com/\nstream.mp3\n")), 3, 1, SeverityWarning, "Not a URL"},
+		{"m3u extinf", NewM3uParser([]byte("#EXTM3U\n#EXTINF:-1,One\n#EXTINF:-1,Two\nhttp://ex.com/\n")), 2, 1, SeverityWarning, "#EXTINF without"},
+		{"m3u extinf eof", NewM3uParser([]byte("#EXTM3U\nhttp://ex.com/\n#EXTINF:-1,One\n")), 3, 1, SeverityWarning, "#EXTINF without"},
+		{"hls variant", NewHlsParser([]byte("#EXTM3U\n#EXT-X-STREAM-INF:BANDWIDTH=1\n")), 2, 1, SeverityWarning, "#EXT-X-STREAM-INF without following"},
+		{"hls bandwidth", NewHlsParser([]byte("#EXTM3U\n#EXT-X-STREAM-INF:CODECS=\"a\"\nlow.m3u8\n")), 2, 1, SeverityWarning, "#EXT-X-STREAM-INF without BANDWIDTH"},
+		{"xspf location", NewXspfParser([]byte("<playlist xmlns=\"http://xspf.org/ns/0/\"><trackList><track><title>T</title></track></trackList></playlist>")), 0, 0, SeverityWarning, "Track 1 without location"},
+	}
+
+	for _, test := range tests {
+
+		if err := test.parser.Parse(); err != nil {
+			t.Fatalf("Unexpected error %s (%s)", err, test.name)
+		}
+
+		diags := test.parser.GetDiagnostics()
+		if len(diags) != 1 {
+			t.Fatalf("Expected 1 diagnostic got %d (%s): %v", len(diags), test.name, diags)
+		}
+
+		d := diags[0]
+		if d.Line != test.line || d.Column != test.column || d.Severity != test.severity || !strings.HasPrefix(d.Message, test.message) {
+			t.Fatalf("Unexpected diagnostic %s (%s)", d, test.name)
+		}
+	}
+}
+
+func TestParserNoDiagnostics(t *testing.T) {
+
+	var files = []string{
+		"./testpls/asf1.asf",
+		"./testpls/asx1.asx",
+		"./testpls/hls1.m3u8",
+		"./testpls/hls2.m3u8",
+		"./testpls/m3u1.m3u",
+		"./testpls/m3u3.m3u",
+		"./testpls/pls1.pls",
+		"./testpls/pls2.pls",
+		"./testpls/xspf1.xspf",
+	}
+
+	for _, filePath := range files {
+
+		plr := new(PlaylistResp)
+		plr.Raw = getPLFile(filePath)
+
+		pl := NewPlaylist(plr)
+		if _, err := pl.Parse(); err != nil {
+			t.Fatalf("Unexpected error %s (%s)", err, filePath)
+		}
+
+		if len(pl.Diagnostics) != 0 {
+			t.Fatalf("Expected no diagnostics for %s got %v", filePath, pl.Diagnostics)
+		}
+	}
+}
+
+func TestXspfParseError(t *testing.T) {
+
+	parser := NewXspfParser([]byte("<playlist xmlns=\"http://xspf.org/ns/0/\">\n<trackList>\n<track></trackList>"))
+
+	if err := parser.Parse(); err == nil {
+		t.Fatalf("Expected error for malformed XML")
+	}
+
+	diags := parser.GetDiagnostics()
+	if len(diags) != 1 || diags[0].Severity != SeverityError || diags[0].Line != 3 {
+		t.Fatalf("Expected error diagnostic on line 3 got %v", diags)
+	}
+}
+
+func TestDiagnosticJson(t *testing.T) {
+
+	d := &Diagnostic{Line: 1, Column: 2, Severity: SeverityWarning, Rule: RULE_NOT_URL, Message: "msg", Text: "text"}
+
+	j, _ := json.Marshal(d)
+	expected := `{"line":1,"column":2,"severity":"warning","rule":"not-url","message":"msg","text":"text"}`
+
+	if string(j) != expected {
+		t.Fatalf("Expected JSON %s got %s", expected, j)
+	}
+}
@@ -0,0 +1,87 @@
+// Part of the Go playlist parser package
+//
+// Copyright 2013 Rafal Zajac rzajac<at>gmail<dot>com. All rights reserved.
+// http://github.com/rzajac/plparser
+//
+// Licensed under the MIT license
+
+package plparser
+
+import (
+	"errors"
+	"strconv"
+	"strings"
+)
+
+// Errors returned by the package. Use errors.Is to check for them
+// as they are usually wrapped with more details or the cause.
+var (
+	ErrTimeout           = errors.New("Timeout connecting to URL.")
+	ErrNotPlaylist       = errors.New("Not a playlist.")
+	ErrUnsupportedFormat = errors.New("Unsupported playlist format.")
+	ErrUnsupportedScheme = errors.New("Unsupported URL scheme.")
+	ErrTooLarge          = errors.New("Playlist too large.")
+	ErrMalformedResponse = errors.New("Malformed response.")
+)
+
+// HTTPStatusError is returned when HTTP response has not successful status code.
+type HTTPStatusError struct {
+	Url        string
+	StatusCode int
+}
+
+// Error returns error message.
+func (e *HTTPStatusError) Error() string {
+	return "Unexpected HTTP status code " + strconv.Itoa(e.StatusCode) + "."
+}
+
+// ParseError is returned when a playlist could not be parsed.
+// It holds the position in the playlist where parsing stopped.
+type ParseError struct {
+	Line   int // 1 based line number, 0 if unknown
+	Column int // 1 based column number, 0 if unknown
+	Err    error
+}
+
+// Error returns error message in form of line:column: message.
+func (e *ParseError) Error() string {
+	return strconv.Itoa(e.Line) + ":" + strconv.Itoa(e.Column) + ": " + e.Err.Error()
+}
+
+// Unwrap returns the underlying error.
+func (e *ParseError) Unwrap() error {
+	return e.Err
+}
+
+// wrapError is one of the package sentinel errors with details and the cause.
+type wrapError struct {
+	kind   error
+	detail string
+	cause  error
+}
+
+// newError returns kind error with details and the cause.
+// Both details and the cause are optional.
+func newError(kind error, detail string, cause error) error {
+	return &wrapError{kind: kind, detail: detail, cause: cause}
+}
+
+// Error returns error message.
+func (e *wrapError) Error() string {
+
+	if e.detail == "" {
+		return e.kind.Error()
+	}
+
+	return strings.TrimSuffix(e.kind.Error(), ".") + ": " + e.detail
+}
+
+// Is returns true if target is the kind of the error.
+func (e *wrapError) Is(target error) bool {
+	return target == e.kind
+}
+
+// Unwrap returns the cause of the error.
+func (e *wrapError) Unwrap() error {
+	return e.cause
+}
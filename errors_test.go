@@ -0,0 +1,73 @@
+// Part of the Go playlist parser package
+//
+// Copyright 2013 Rafal Zajac rzajac<at>gmail<dot>com. All rights reserved.
+// http://github.com/rzajac/plparser
+//
+// Licensed under the MIT license
+
+package plparser
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"testing"
+)
+
+func TestErrorKinds(t *testing.T) {
+
+	cause := io.ErrUnexpectedEOF
+	err := newError(ErrTimeout, "", cause)
+
+	if !errors.Is(err, ErrTimeout) || !errors.Is(err, cause) || errors.Is(err, ErrTooLarge) {
+		t.Fatalf("Unexpected error kind %v", err)
+	}
+
+	if err.Error() != "Timeout connecting to URL." {
+		t.Fatalf("Unexpected error message %s", err)
+	}
+
+	err = newError(ErrUnsupportedFormat, "bad", nil)
+	if err.Error() != "Unsupported playlist format: bad" {
+		t.Fatalf("Unexpected error message %s", err)
+	}
+}
+
+func TestWriteUnsupportedError(t *testing.T) {
+
+	var buf bytes.Buffer
+
+	if err := WritePlaylist(&buf, "bad", nil); !errors.Is(err, ErrUnsupportedFormat) {
+		t.Fatalf("Expected ErrUnsupportedFormat got %v", err)
+	}
+}
+
+func TestHTTPStatusError(t *testing.T) {
+
+	var err error = &HTTPStatusError{Url: "http://ex.com/", StatusCode: 404}
+
+	var serr *HTTPStatusError
+	if !errors.As(err, &serr) || serr.StatusCode != 404 {
+		t.Fatalf("Expected HTTPStatusError got %v", err)
+	}
+
+	if err.Error() != "Unexpected HTTP status code 404." {
+		t.Fatalf("Unexpected error message %s", err)
+	}
+}
+
+func TestParseErrorPosition(t *testing.T) {
+
+	p := NewXspfParser([]byte("<playlist xmlns=\"http://xspf.org/ns/0/\">\n<trackList>\n<track></trackList>"))
+
+	err := p.Parse()
+
+	var perr *ParseError
+	if !errors.As(err, &perr) || perr.Line != 3 {
+		t.Fatalf("Expected parse error on line 3 got %v", err)
+	}
+
+	if perr.Error() != "3:0: "+perr.Err.Error() {
+		t.Fatalf("Unexpected error message %s", perr)
+	}
+}
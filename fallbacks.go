@@ -0,0 +1,141 @@
+// Part of the Go playlist parser package
+//
+// Copyright 2013 Rafal Zajac rzajac<at>gmail<dot>com. All rights reserved.
+// http://github.com/rzajac/plparser
+//
+// Licensed under the MIT license
+
+package plparser
+
+import (
+	"context"
+	"errors"
+	"net/url"
+	"regexp"
+	"strings"
+)
+
+// ErrNoStreamUrl is returned by TryStream when stream has no URL to try.
+var ErrNoStreamUrl = errors.New("Stream has no URL.")
+
+// mirrorTitleReg matches mirror number added to titles of PLS entries
+// for example "(#1)" or "(#2 - 15/500)" used by Shoutcast.
+var mirrorTitleReg = regexp.MustCompile(`^\s*\(#\d+[^)]*\)\s*|\s*\(#\d+[^)]*\)\s*$`)
+
+// MirrorGrouper is implemented by parsers which can group entries
+// pointing to the same stream on different servers.
+type MirrorGrouper interface {
+	// SetGroupMirrors makes parser group mirror entries to one stream
+	// with Fallbacks. Must be called before Parse.
+	SetGroupMirrors(group bool)
+}
+
+// Urls returns URLs of the stream in order they should be tried:
+// the Url, its Alternatives and then Fallbacks.
+func (s *Stream) Urls() []string {
+
+	urls := make([]string, 0, 1+len(s.Alternatives)+len(s.Fallbacks))
+
+	for _, u := range append(append([]string{s.Url}, s.Alternatives...), s.Fallbacks...) {
+		if u != "" && !inChain(u, urls) {
+			urls = append(urls, u)
+		}
+	}
+
+	return urls
+}
+
+// TryStream calls try with stream URLs in order returned by Urls until
+// one of the calls succeeds. Returns the URL try succeeded with or the
+// error of the last call. Stops when ctx is done.
+func TryStream(ctx context.Context, s *Stream, try func(ctx context.Context, url string) error) (string, error) {
+
+	err := ErrNoStreamUrl
+
+	for _, u := range s.Urls() {
+
+		if ctx.Err() != nil {
+			return "", contextError(ctx.Err())
+		}
+
+		if err = try(ctx, u); err == nil {
+			return u, nil
+		}
+	}
+
+	return "", err
+}
+
+// groupMirrors groups mirror streams to the first of them. URLs of the
+// other mirrors are added to its Fallbacks.
+func groupMirrors(streams []*Stream) []*Stream {
+
+	grouped := make([]*Stream, 0, len(streams))
+
+	for _, s := range streams {
+
+		var primary *Stream
+
+		for _, g := range grouped {
+			if isMirror(g, s) {
+				primary = g
+				break
+			}
+		}
+
+		if primary == nil {
+			grouped = append(grouped, s)
+			continue
+		}
+
+		primary.Fallbacks = append(primary.Fallbacks, s.Url)
+	}
+
+	return grouped
+}
+
+// isMirror returns true if stream b is a mirror of stream a: the URLs
+// differ only by host or port and titles differ only by mirror number.
+func isMirror(a, b *Stream) bool {
+
+	if mirrorTitle(a.Title) != mirrorTitle(b.Title) {
+		return false
+	}
+
+	ua, err := url.Parse(a.Url)
+	if err != nil || !ua.IsAbs() {
+		return false
+	}
+
+	ub, err := url.Parse(b.Url)
+	if err != nil || !ub.IsAbs() {
+		return false
+	}
+
+	if !strings.EqualFold(ua.Scheme, ub.Scheme) || mirrorPath(ua) != mirrorPath(ub) || ua.RawQuery != ub.RawQuery {
+		return false
+	}
+
+	// The same server is not a mirror
+	for _, u := range append([]string{a.Url}, a.Fallbacks...) {
+		if uf, err := url.Parse(u); err == nil && strings.EqualFold(uf.Host, ub.Host) {
+			return false
+		}
+	}
+
+	return true
+}
+
+// mirrorTitle returns title with mirror number removed.
+func mirrorTitle(title string) string {
+	return mirrorTitleReg.ReplaceAllString(title, "")
+}
+
+// mirrorPath returns URL path with empty path treated as "/".
+func mirrorPath(u *url.URL) string {
+	if u.Path == "" {
+		return "/"
+	}
+
+	return u.Path
+}
@@ -0,0 +1,114 @@
+// Part of the Go playlist parser package
+//
+// Copyright 2013 Rafal Zajac rzajac<at>gmail<dot>com. All rights reserved.
+// http://github.com/rzajac/plparser
+//
+// Licensed under the MIT license
+
+package plparser
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"reflect"
+	"testing"
+)
+
+func TestPlsGroupMirrors(t *testing.T) {
+
+	raw := "[playlist]\n" +
+		"File1=http://ice1.ex.com/groove-128\nTitle1=Groove (#1)\n" +
+		"File2=http://ice2.ex.com/groove-128\nTitle2=Groove (#2)\n" +
+		"File3=http://ice1.ex.com/drone-128\nTitle3=Drone (#1)\n" +
+		"File4=http://ice3.ex.com:8000/groove-128\nTitle4=Groove (#3)\n" +
+		"File5=http://ice1.ex.com/groove-128\nTitle5=Groove (#4)\n" +
+		"NumberOfEntries=5\nVersion=2\n"
+
+	pls := NewPlsParser([]byte(raw))
+	pls.SetGroupMirrors(true)
+	pls.Parse()
+
+	if len(pls.Streams) != 3 {
+		t.Fatalf("Expected 3 streams got %d", len(pls.Streams))
+	}
+
+	expected := []string{"http://ice2.ex.com/groove-128", "http://ice3.ex.com:8000/groove-128"}
+	if s := pls.Streams[0]; s.Index != 1 || !reflect.DeepEqual(s.Fallbacks, expected) {
+		t.Fatalf("Expected stream 1 with fallbacks %v got %d %v", expected, s.Index, s.Fallbacks)
+	}
+
+	if s := pls.Streams[1]; s.Index != 3 || s.Fallbacks != nil {
+		t.Fatalf("Expected stream 3 with no fallbacks got %d %v", s.Index, s.Fallbacks)
+	}
+
+	// The same server is not a mirror
+	if s := pls.Streams[2]; s.Index != 5 {
+		t.Fatalf("Expected stream 5 got %d", s.Index)
+	}
+
+	// Mirrors are not grouped by default
+	pl, _ := ParseReader(context.Background(), bytes.NewReader([]byte(raw)), nil)
+	if len(pl.Streams) != 5 {
+		t.Fatalf("Expected 5 streams got %d", len(pl.Streams))
+	}
+
+	pl, _ = ParseReader(context.Background(), bytes.NewReader(getPLFile("./testpls/pls1.pls")), &ParseOptions{GroupMirrors: true})
+	if len(pl.Streams) != 2 || len(pl.Streams[0].Fallbacks) != 3 || pl.Streams[1].Title != "Some example title" {
+		t.Fatalf("Expected port mirrors to be grouped got %d streams", len(pl.Streams))
+	}
+}
+
+func TestStreamUrls(t *testing.T) {
+
+	s := NewStream(1)
+	s.Url = "mms://ex.com/live"
+	s.Alternatives = []string{"mmsh://ex.com/live", "http://ex.com/live"}
+	s.Fallbacks = []string{"http://mirror.ex.com/live", "http://ex.com/live"}
+
+	expected := []string{"mms://ex.com/live", "mmsh://ex.com/live", "http://ex.com/live", "http://mirror.ex.com/live"}
+	if urls := s.Urls(); !reflect.DeepEqual(urls, expected) {
+		t.Fatalf("Expected %v got %v", expected, urls)
+	}
+}
+
+func TestTryStream(t *testing.T) {
+
+	s := NewStream(1)
+	s.Url = "http://ex.com/live"
+	s.Fallbacks = []string{"http://mirror1.ex.com/live", "http://mirror2.ex.com/live"}
+
+	var tried []string
+	errDown := errors.New("down")
+
+	u, err := TryStream(context.Background(), s, func(ctx context.Context, url string) error {
+		tried = append(tried, url)
+		if url == "http://mirror1.ex.com/live" {
+			return nil
+		}
+		return errDown
+	})
+
+	if err != nil || u != "http://mirror1.ex.com/live" || len(tried) != 2 {
+		t.Fatalf("Expected first working fallback got %s %v (tried %v)", u, err, tried)
+	}
+
+	_, err = TryStream(context.Background(), s, func(ctx context.Context, url string) error {
+		return errDown
+	})
+
+	if err != errDown {
+		t.Fatalf("Expected error of the last URL got %v", err)
+	}
+
+	if _, err = TryStream(context.Background(), NewStream(1), nil); err != ErrNoStreamUrl {
+		t.Fatalf("Expected ErrNoStreamUrl got %v", err)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	if _, err = TryStream(ctx, s, func(ctx context.Context, url string) error { return nil }); err != context.Canceled {
+		t.Fatalf("Expected context.Canceled got %v", err)
+	}
+}
@@ -0,0 +1,154 @@
+// Part of the Go playlist parser package
+//
+// Copyright 2013 Rafal Zajac rzajac<at>gmail<dot>com. All rights reserved.
+// http://github.com/rzajac/plparser
+//
+// Licensed under the MIT license
+
+package plparser
+
+import (
+	"context"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"strconv"
+	"strings"
+)
+
+// DefaultUserAgent is the User-Agent header sent by Fetcher.
+const DefaultUserAgent = "plparser (+http://github.com/rzajac/plparser)"
+
+// Fetcher fetches potential playlists over HTTP.
+type Fetcher struct {
+	// Client is the HTTP client used to make requests.
+	Client *http.Client
+	// UserAgent is sent with every request. Empty means DefaultUserAgent.
+	UserAgent string
+	// Header holds additional headers sent with every request.
+	Header http.Header
+	// MaxSize is the maximum number of bytes read from text responses.
+	// Larger responses are returned with ErrTooLarge. Zero means DefaultMaxSize.
+	MaxSize int64
+}
+
+// NewFetcher returns new fetcher using given HTTP client.
+// If client is nil http.DefaultClient is used.
+func NewFetcher(client *http.Client) *Fetcher {
+	if client == nil {
+		client = http.DefaultClient
+	}
+
+	f := new(Fetcher)
+	f.Client = client
+	f.Header = make(http.Header)
+	return f
+}
+
+// NewFetcherTransport returns new fetcher making requests with given transport.
+func NewFetcherTransport(rt http.RoundTripper) *Fetcher {
+	return NewFetcher(&http.Client{Transport: rt})
+}
+
+// Fetch fetches potential playlist from URL. The ctx controls the deadline
+// and cancellation of the request including reading of the response body.
+// Responses with not successful status code are returned together with
+// *HTTPStatusError, their body is not read.
+func (f *Fetcher) Fetch(ctx context.Context, url string) (*PlaylistResp, error) {
+
+	plr := new(PlaylistResp)
+	plr.Url = url
+	plr.Origin = ORIGIN_URL
+
+	req, err := http.NewRequest("GET", url, nil)
+	if err != nil {
+		return plr, err
+	}
+	req = req.WithContext(ctx)
+
+	for name, values := range f.Header {
+		for _, value := range values {
+			req.Header.Add(name, value)
+		}
+	}
+
+	if f.UserAgent != "" {
+		req.Header.Set("User-Agent", f.UserAgent)
+	} else if req.Header.Get("User-Agent") == "" {
+		req.Header.Set("User-Agent", DefaultUserAgent)
+	}
+
+	client := f.Client
+	if client == nil {
+		client = http.DefaultClient
+	}
+
+	resp, err := client.Do(req)
+
+	if err != nil {
+		// This means that ShoutCast server responded with its header
+		// which is not recognized by http package.
+		// The header is usually in the form of ICY 200 OK
+		// In this case we set the response to be 200 but containing binary data.
+		// Use IcyClient to get information about such streams.
+		if strings.Contains(err.Error(), "malformed HTTP version \"ICY\"") {
+			plr.StatusCode = 200
+			plr.ContentType = "application/octet-stream"
+			plr.ContentTypeDetected = "application/octet-stream"
+			return plr, nil
+		}
+
+		return plr, fetchError(ctx, err)
+	}
+
+	defer resp.Body.Close()
+
+	plr.StatusCode = resp.StatusCode
+	plr.ContentType = resp.Header.Get("Content-Type")
+
+	if !(plr.StatusCode >= 200 && plr.StatusCode < 300) {
+		return plr, &HTTPStatusError{Url: url, StatusCode: plr.StatusCode}
+	}
+
+	maxSize := f.MaxSize
+	if maxSize <= 0 {
+		maxSize = DefaultMaxSize
+	}
+
+	// If it's text response we read whole content
+	// otherwise only playlistReadLimit bytes so we can use DetectContentType
+	if isTextType(plr.ContentType) {
+		plr.Raw, err = ioutil.ReadAll(io.LimitReader(resp.Body, maxSize+1))
+	} else {
+		plr.Raw, err = ioutil.ReadAll(io.LimitReader(resp.Body, playlistReadLimit))
+	}
+
+	if err != nil {
+		return plr, fetchError(ctx, err)
+	}
+
+	if int64(len(plr.Raw)) > maxSize {
+		plr.Raw = plr.Raw[:maxSize]
+		return plr, newError(ErrTooLarge, "exceeds "+strconv.FormatInt(maxSize, 10)+" bytes", nil)
+	}
+
+	plr.ContentTypeDetected = http.DetectContentType(plr.Raw)
+
+	return plr, nil
+}
+
+// isTextType returns true if content type is one of TEXT content types
+// or a MIME type of registered playlist format.
+func isTextType(contentType string) bool {
+	return TEXT[contentType] || TEXT[mediaType(contentType)] || LookupMimeType(contentType) != nil
+}
+
+// fetchError returns timeout error if ctx deadline was exceeded
+// or err otherwise.
+func fetchError(ctx context.Context, err error) error {
+	if ctx.Err() == context.DeadlineExceeded {
+		return newError(ErrTimeout, "", err)
+	}
+
+	return err
+}
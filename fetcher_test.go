@@ -0,0 +1,217 @@
+// Part of the Go playlist parser package
+//
+// Copyright 2013 Rafal Zajac rzajac<at>gmail<dot>com. All rights reserved.
+// http://github.com/rzajac/plparser
+//
+// Licensed under the MIT license
+
+package plparser
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestFetcherFetch(t *testing.T) {
+
+	raw := getPLFile("./testpls/pls1.pls")
+
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+
+		if r.Header.Get("User-Agent") != "test-agent" {
+			t.Errorf("Expected User-Agent 'test-agent' got '%s'", r.Header.Get("User-Agent"))
+		}
+
+		if r.Header.Get("X-Test") != "value" {
+			t.Errorf("Expected X-Test header 'value' got '%s'", r.Header.Get("X-Test"))
+		}
+
+		w.Header().Set("Content-Type", "audio/x-scpls")
+		w.Write(raw)
+	}))
+	defer ts.Close()
+
+	f := NewFetcher(ts.Client())
+	f.UserAgent = "test-agent"
+	f.Header.Set("X-Test", "value")
+
+	plr, err := f.Fetch(context.Background(), ts.URL)
+	if err != nil {
+		t.Fatalf("Unexpected error %s", err)
+	}
+
+	if plr.StatusCode != 200 || plr.ContentType != "audio/x-scpls" || plr.Origin != ORIGIN_URL {
+		t.Fatalf("Unexpected response %d, '%s', '%s'", plr.StatusCode, plr.ContentType, plr.Origin)
+	}
+
+	if string(plr.Raw) != string(raw) {
+		t.Fatalf("Expected body '%s' got '%s'", raw, plr.Raw)
+	}
+}
+
+func TestFetcherWpl(t *testing.T) {
+
+	raw := getPLFile("./testpls/wpl1.wpl")
+
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/vnd.ms-wpl")
+		w.Write(raw)
+	}))
+	defer ts.Close()
+
+	plr, err := NewFetcher(ts.Client()).Fetch(context.Background(), ts.URL+"/list.wpl")
+	if err != nil {
+		t.Fatalf("Unexpected error %s", err)
+	}
+
+	if len(raw) <= playlistReadLimit || len(plr.Raw) != len(raw) {
+		t.Fatalf("Expected whole body of %d bytes got %d", len(raw), len(plr.Raw))
+	}
+
+	pl := NewPlaylist(plr)
+	if pltype, err := pl.Parse(); err != nil || pltype != "wpl" || len(pl.Streams) != 4 {
+		t.Fatalf("Expected wpl playlist with 4 streams got '%s' %d (%v)", pltype, len(pl.Streams), err)
+	}
+}
+
+func TestFetcherRegisteredMimeType(t *testing.T) {
+
+	RegisterFormat(&Format{
+		Name:      "fetchtest",
+		MimeTypes: []string{"application/x-fetchtest"},
+		New:       func(raw []byte) Playlister { return &jsonTestParser{raw: raw} },
+	})
+
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/x-fetchtest; charset=utf-8")
+		w.Write(make([]byte, playlistReadLimit*4))
+	}))
+	defer ts.Close()
+
+	plr, err := NewFetcher(ts.Client()).Fetch(context.Background(), ts.URL)
+	if err != nil {
+		t.Fatalf("Unexpected error %s", err)
+	}
+
+	if len(plr.Raw) != playlistReadLimit*4 {
+		t.Fatalf("Expected whole body of registered format got %d bytes", len(plr.Raw))
+	}
+}
+
+func TestFetcherStatus(t *testing.T) {
+
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.NotFound(w, r)
+	}))
+	defer ts.Close()
+
+	plr, err := NewFetcher(ts.Client()).Fetch(context.Background(), ts.URL+"/missing.pls")
+
+	var serr *HTTPStatusError
+	if !errors.As(err, &serr) || serr.StatusCode != 404 || serr.Url != ts.URL+"/missing.pls" {
+		t.Fatalf("Expected HTTPStatusError with status 404 got %v", err)
+	}
+
+	if plr.StatusCode != 404 {
+		t.Fatalf("Expected response status 404 got %d", plr.StatusCode)
+	}
+}
+
+func TestFetcherTooLarge(t *testing.T) {
+
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "audio/x-mpegurl")
+		w.Write([]byte(strings.Repeat("http://ex.com/stream.mp3\n", 100)))
+	}))
+	defer ts.Close()
+
+	f := NewFetcher(ts.Client())
+	f.MaxSize = 1000
+
+	if _, err := f.Fetch(context.Background(), ts.URL); !errors.Is(err, ErrTooLarge) {
+		t.Fatalf("Expected ErrTooLarge got %v", err)
+	}
+
+	f.MaxSize = 2500
+
+	plr, err := f.Fetch(context.Background(), ts.URL)
+	if err != nil || len(plr.Raw) != 2500 {
+		t.Fatalf("Expected whole body of 2500 bytes got %d (%v)", len(plr.Raw), err)
+	}
+}
+
+func TestFetcherBinary(t *testing.T) {
+
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "audio/mpeg")
+		w.Write(make([]byte, playlistReadLimit*4))
+	}))
+	defer ts.Close()
+
+	plr, err := NewFetcher(nil).Fetch(context.Background(), ts.URL)
+	if err != nil {
+		t.Fatalf("Unexpected error %s", err)
+	}
+
+	if len(plr.Raw) != playlistReadLimit {
+		t.Fatalf("Expected to read %d bytes got %d", playlistReadLimit, len(plr.Raw))
+	}
+
+	if plr.IsPotentialPlaylist() {
+		t.Fatalf("Expected binary response not to be a playlist")
+	}
+}
+
+func TestFetcherTimeout(t *testing.T) {
+
+	done := make(chan struct{})
+
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		select {
+		case <-done:
+		case <-r.Context().Done():
+		}
+	}))
+	defer ts.Close()
+	defer close(done)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+
+	_, err := NewFetcherTransport(http.DefaultTransport).Fetch(ctx, ts.URL)
+	if !errors.Is(err, ErrTimeout) || !errors.Is(err, context.DeadlineExceeded) || err.Error() != "Timeout connecting to URL." {
+		t.Fatalf("Expected timeout error got %v", err)
+	}
+
+	ctx, cancel = context.WithCancel(context.Background())
+	cancel()
+
+	_, err = NewFetcher(nil).Fetch(ctx, ts.URL)
+	if err == nil {
+		t.Fatalf("Expected error for canceled context")
+	}
+}
+
+func TestNewPlaylistRespUrl(t *testing.T) {
+
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "audio/x-mpegurl")
+		w.Write(getPLFile("./testpls/m3u1.m3u"))
+	}))
+	defer ts.Close()
+
+	plr, err := NewPlaylistRespUrl(ts.URL, 5)
+	if err != nil {
+		t.Fatalf("Unexpected error %s", err)
+	}
+
+	pl := NewPlaylist(plr)
+	if pltype, _ := pl.Parse(); pltype != "m3u" || len(pl.Streams) != 2 {
+		t.Fatalf("Expected m3u playlist with 2 streams got '%s' with %d", pltype, len(pl.Streams))
+	}
+}
@@ -8,6 +8,9 @@
 package plparser
 
 import (
+	"bytes"
+	"encoding/xml"
+	"net/url"
 	"strings"
 )
 
@@ -20,14 +23,39 @@ func fixString(s string) string {
 	return v
 }
 
-// isUrl is a helper function returning true if passed text is a URL.
+// isUrl is a helper function returning true if passed text is a URL
+// with one of the schemes accepted by the default scheme policy.
 func isUrl(text string) bool {
+	u, err := url.Parse(text)
+	return err == nil && u.Scheme != "" && defaultSchemePolicy.IsAccepted(u.Scheme)
+}
 
-	var ok bool
+// xmlRoot returns lowercased local name and namespace of the root element
+// of an XML document. Returns empty strings if no element was found.
+func xmlRoot(raw []byte) (name, space string) {
 
-	if text != "" && (strings.HasPrefix(text, "http") || strings.HasPrefix(text, "mms")) {
-		ok = true
-	}
+	decoder := xml.NewDecoder(bytes.NewReader(raw))
+	decoder.Strict = false
+	decoder.CharsetReader = xmlCharsetReader
+
+	for {
+		token, err := decoder.RawToken()
+		if err != nil {
+			return
+		}
 
-	return ok
+		if el, ok := token.(xml.StartElement); ok {
+			name = strings.ToLower(el.Name.Local)
+
+			// RawToken does not resolve namespaces so we
+			// have to look for xmlns attribute ourselves
+			for _, attr := range el.Attr {
+				if attr.Name.Space == "" && attr.Name.Local == "xmlns" {
+					space = attr.Value
+				}
+			}
+
+			return
+		}
+	}
 }
@@ -0,0 +1,333 @@
+// Part of the Go playlist parser package
+//
+// Copyright 2013 Rafal Zajac rzajac<at>gmail<dot>com. All rights reserved.
+// http://github.com/rzajac/plparser
+//
+// Licensed under the MIT license
+
+package plparser
+
+import (
+	"bufio"
+	"bytes"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+)
+
+func init() {
+	RegisterFormat(&Format{
+		Name:       "hls",
+		MimeTypes:  []string{"application/vnd.apple.mpegurl", "application/x-mpegurl"},
+		Extensions: []string{".m3u8"},
+		Detect:     detectHls,
+		New:        func(raw []byte) Playlister { return NewHlsParser(raw) },
+	})
+}
+
+// HlsVariant represents a variant stream from #EXT-X-STREAM-INF tag.
+type HlsVariant struct {
+	Bandwidth        int    `json:"bandwidth"`
+	AverageBandwidth int    `json:"avg_bandwidth"`
+	Codecs           string `json:"codecs"`
+	Resolution       string `json:"resolution"`
+	FrameRate        string `json:"frame_rate"`
+	Audio            string `json:"audio"`
+	Video            string `json:"video"`
+	Subtitles        string `json:"subtitles"`
+	Url              string `json:"url"`
+}
+
+// HlsRendition represents an alternative rendition from #EXT-X-MEDIA tag.
+type HlsRendition struct {
+	Type       string `json:"type"`
+	GroupId    string `json:"group_id"`
+	Name       string `json:"name"`
+	Language   string `json:"language"`
+	Default    bool   `json:"default"`
+	Autoselect bool   `json:"autoselect"`
+	Url        string `json:"url"`
+}
+
+// HlsSegment represents a media segment of HLS media playlist.
+type HlsSegment struct {
+	Sequence int           `json:"sequence"`
+	Duration time.Duration `json:"duration"`
+	Title    string        `json:"title"`
+	Url      string        `json:"url"`
+}
+
+// HlsParser implements HLS (M3U8) master and media playlist parser.
+type HlsParser struct {
+	Diagnostics
+	UrlResolver
+	raw            []byte
+	reader         *bufio.Reader
+	Version        int
+	TargetDuration time.Duration
+	MediaSequence  int
+	EndList        bool
+	Variants       []*HlsVariant
+	Renditions     []*HlsRendition
+	Segments       []*HlsSegment
+	Streams        []*Stream
+}
+
+// NewHlsParser returns new HLS playlist parser. Takes playlist raw content to parse.
+func NewHlsParser(raw []byte) *HlsParser {
+	hls := new(HlsParser)
+	hls.raw = raw
+	hls.Variants = make([]*HlsVariant, 0, 10)
+	hls.Renditions = make([]*HlsRendition, 0, 10)
+	hls.Segments = make([]*HlsSegment, 0, 10)
+	hls.Streams = make([]*Stream, 0, 10)
+
+	br := bytes.NewReader(hls.raw)
+	hls.reader = bufio.NewReader(br)
+	return hls
+}
+
+// Parse parses a HLS playlist.
+func (p *HlsParser) Parse() error {
+
+	// Tags describing the URI on the next line
+	var variant *HlsVariant
+	var segment *HlsSegment
+	var lineNo, tagLine int
+	var tagText string
+
+	// Validation state
+	var headerSeen, uriSeen, targetDurationSeen bool
+
+	for {
+		line, err := p.reader.ReadString('\n')
+		lineNo++
+
+		if err != nil && err != io.EOF {
+			p.AddDiagnostic(lineNo, 0, SeverityError, RULE_READ_ERROR, "Read error: "+err.Error(), "")
+			return &ParseError{Line: lineNo, Err: err}
+		}
+
+		line = fixString(line)
+
+		if line != "" && !headerSeen {
+			headerSeen = true
+			if !strings.HasPrefix(line, "#EXTM3U") {
+				p.AddFinding(lineNo, 1, SeverityError, RULE_HLS_MISSING_EXTM3U, "Playlist does not start with #EXTM3U", line)
+			}
+		}
+
+		if uriSeen && isHlsHeaderTag(line) {
+			p.AddFinding(lineNo, 1, SeverityWarning, RULE_HLS_TAG_ORDER, "Playlist tag after the first URI", line)
+		}
+
+		switch {
+		case line == "":
+
+		case strings.HasPrefix(line, "#EXT-X-STREAM-INF:"):
+			if variant != nil {
+				p.AddDiagnostic(tagLine, 1, SeverityWarning, RULE_HLS_STREAM_INF_WITHOUT_URI, "#EXT-X-STREAM-INF without following URI", tagText)
+			}
+
+			variant = newHlsVariant(parseHlsAttributes(line[len("#EXT-X-STREAM-INF:"):]))
+			tagLine, tagText = lineNo, line
+
+			if variant.Bandwidth == 0 {
+				p.AddDiagnostic(lineNo, 1, SeverityWarning, RULE_HLS_MISSING_BANDWIDTH, "#EXT-X-STREAM-INF without BANDWIDTH", line)
+			}
+
+		case strings.HasPrefix(line, "#EXT-X-MEDIA:"):
+			rendition := newHlsRendition(parseHlsAttributes(line[len("#EXT-X-MEDIA:"):]))
+			if rendition.Url != "" {
+				rendition.Url = p.resolveUrl(rendition.Url)
+			}
+			p.Renditions = append(p.Renditions, rendition)
+
+		case strings.HasPrefix(line, "#EXT-X-TARGETDURATION:"):
+			secs, _ := strconv.Atoi(line[len("#EXT-X-TARGETDURATION:"):])
+			targetDurationSeen = true
+			p.TargetDuration = time.Duration(secs) * time.Second
+
+		case strings.HasPrefix(line, "#EXT-X-MEDIA-SEQUENCE:"):
+			p.MediaSequence, _ = strconv.Atoi(line[len("#EXT-X-MEDIA-SEQUENCE:"):])
+
+		case strings.HasPrefix(line, "#EXT-X-VERSION:"):
+			p.Version, _ = strconv.Atoi(line[len("#EXT-X-VERSION:"):])
+
+		case line == "#EXT-X-ENDLIST":
+			p.EndList = true
+
+		case strings.HasPrefix(line, "#EXTINF:"):
+			if segment != nil {
+				p.AddDiagnostic(tagLine, 1, SeverityWarning, RULE_EXTINF_WITHOUT_URI, "#EXTINF without following URI", tagText)
+			}
+
+			tagLine, tagText = lineNo, line
+			segment = new(HlsSegment)
+			segment.Duration, segment.Title, _ = parseExtinf(line[len("#EXTINF:"):])
+
+		case strings.HasPrefix(line, "#"):
+			// Comment or a tag we do not care about
+
+		default:
+			// Every line which is not a tag is an URI
+			uriSeen = true
+			stream := NewStream(len(p.Streams) + 1)
+			stream.OriginalUrl = line
+			stream.Url = p.resolveUrl(line)
+
+			// Relative URIs are common in HLS playlists with unknown
+			// location so only absolute URLs are checked
+			if isAbsUrl(stream.Url) && !p.checkStream(&p.Diagnostics, stream, lineNo, 1, line) {
+				variant, segment = nil, nil
+				break
+			}
+
+			if variant != nil {
+				variant.Url = stream.Url
+				p.Variants = append(p.Variants, variant)
+				variant = nil
+			} else {
+				if segment == nil {
+					segment = new(HlsSegment)
+				}
+				segment.Sequence = p.MediaSequence + len(p.Segments)
+				segment.Url = stream.Url
+				p.Segments = append(p.Segments, segment)
+
+				stream.Title = segment.Title
+				stream.Duration = segment.Duration
+				segment = nil
+			}
+
+			p.Streams = append(p.Streams, stream)
+		}
+
+		if err == io.EOF {
+			break
+		}
+	}
+
+	if variant != nil {
+		p.AddDiagnostic(tagLine, 1, SeverityWarning, RULE_HLS_STREAM_INF_WITHOUT_URI, "#EXT-X-STREAM-INF without following URI", tagText)
+	}
+
+	if segment != nil {
+		p.AddDiagnostic(tagLine, 1, SeverityWarning, RULE_EXTINF_WITHOUT_URI, "#EXTINF without following URI", tagText)
+	}
+
+	if len(p.Segments) > 0 && !targetDurationSeen {
+		p.AddFinding(0, 0, SeverityError, RULE_HLS_MISSING_TARGET_DURATION, "Media playlist without #EXT-X-TARGETDURATION", "")
+	}
+
+	return nil
+}
+
+// isHlsHeaderTag returns true if line is a tag which must appear
+// before the first media segment.
+func isHlsHeaderTag(line string) bool {
+	return strings.HasPrefix(line, "#EXT-X-TARGETDURATION:") ||
+		strings.HasPrefix(line, "#EXT-X-MEDIA-SEQUENCE:") ||
+		strings.HasPrefix(line, "#EXT-X-VERSION:")
+}
+
+// GetStreams gets list of found streams in the playlist.
+func (p *HlsParser) GetStreams() []*Stream {
+	return p.Streams
+}
+
+// GetInfo gets playlist level metadata.
+func (p *HlsParser) GetInfo() *PlaylistInfo {
+
+	info := new(PlaylistInfo)
+
+	if p.Version > 0 {
+		info.Version = strconv.Itoa(p.Version)
+	}
+
+	return info
+}
+
+// IsMaster returns true if parsed playlist is a HLS master playlist.
+func (p *HlsParser) IsMaster() bool {
+	return len(p.Variants) > 0
+}
+
+// newHlsVariant creates HlsVariant from #EXT-X-STREAM-INF attributes.
+func newHlsVariant(attrs map[string]string) *HlsVariant {
+	v := new(HlsVariant)
+	v.Bandwidth, _ = strconv.Atoi(attrs["BANDWIDTH"])
+	v.AverageBandwidth, _ = strconv.Atoi(attrs["AVERAGE-BANDWIDTH"])
+	v.Codecs = attrs["CODECS"]
+	v.Resolution = attrs["RESOLUTION"]
+	v.FrameRate = attrs["FRAME-RATE"]
+	v.Audio = attrs["AUDIO"]
+	v.Video = attrs["VIDEO"]
+	v.Subtitles = attrs["SUBTITLES"]
+	return v
+}
+
+// newHlsRendition creates HlsRendition from #EXT-X-MEDIA attributes.
+func newHlsRendition(attrs map[string]string) *HlsRendition {
+	r := new(HlsRendition)
+	r.Type = attrs["TYPE"]
+	r.GroupId = attrs["GROUP-ID"]
+	r.Name = attrs["NAME"]
+	r.Language = attrs["LANGUAGE"]
+	r.Default = attrs["DEFAULT"] == "YES"
+	r.Autoselect = attrs["AUTOSELECT"] == "YES"
+	r.Url = attrs["URI"]
+	return r
+}
+
+// parseHlsAttributes parses HLS attribute list in form of KEY=VALUE,KEY="VALUE".
+// Quoted values may contain commas.
+func parseHlsAttributes(text string) map[string]string {
+
+	attrs := make(map[string]string, 10)
+
+	for text != "" {
+
+		eq := strings.IndexByte(text, '=')
+		if eq == -1 {
+			break
+		}
+
+		key := strings.ToUpper(strings.TrimSpace(text[:eq]))
+		text = text[eq+1:]
+
+		var value string
+
+		if strings.HasPrefix(text, "\"") {
+			end := strings.IndexByte(text[1:], '"')
+			if end == -1 {
+				value, text = text[1:], ""
+			} else {
+				value, text = text[1:end+1], text[end+2:]
+			}
+		} else {
+			end := strings.IndexByte(text, ',')
+			if end == -1 {
+				value, text = text, ""
+			} else {
+				value, text = text[:end], text[end:]
+			}
+		}
+
+		attrs[key] = strings.TrimSpace(value)
+		text = strings.TrimLeft(text, ", ")
+	}
+
+	return attrs
+}
+
+// detectHls returns true if playlist is a HLS playlist.
+func detectHls(header string, raw []byte) bool {
+	return isM3uHeader(header) && isHls(raw)
+}
+
+// isHls returns true if M3U playlist has HLS (EXT-X) tags.
+func isHls(raw []byte) bool {
+	return bytes.Contains(raw, []byte("#EXT-X-"))
+}
@@ -0,0 +1,136 @@
+// Part of the Go playlist parser package
+//
+// Copyright 2013 Rafal Zajac rzajac<at>gmail<dot>com. All rights reserved.
+// http://github.com/rzajac/plparser
+//
+// Licensed under the MIT license
+
+package plparser
+
+import (
+	"testing"
+	"time"
+)
+
+func TestHlsAttributes(t *testing.T) {
+
+	var testLines = []struct {
+		line     string
+		key      string
+		expected string
+		count    int
+	}{
+		{`BANDWIDTH=1280000`, "BANDWIDTH", "1280000", 1},
+		{`BANDWIDTH=1280000,CODECS="avc1.4d401f,mp4a.40.2"`, "CODECS", "avc1.4d401f,mp4a.40.2", 2},
+		{`CODECS="avc1.4d401f,mp4a.40.2",BANDWIDTH=1280000`, "BANDWIDTH", "1280000", 2},
+		{`TYPE=AUDIO, GROUP-ID="aac"`, "GROUP-ID", "aac", 2},
+		{`resolution=640x360`, "RESOLUTION", "640x360", 1},
+		{``, "", "", 0},
+	}
+
+	for _, testLine := range testLines {
+		attrs := parseHlsAttributes(testLine.line)
+
+		if len(attrs) != testLine.count {
+			t.Fatalf("Expected %d attributes got %d for '%s'", testLine.count, len(attrs), testLine.line)
+		}
+
+		if attrs[testLine.key] != testLine.expected {
+			t.Fatalf("Expected %s '%s' == '%s'", testLine.key, testLine.expected, attrs[testLine.key])
+		}
+	}
+}
+
+func TestHlsMaster(t *testing.T) {
+
+	parser := NewHlsParser(getPLFile("./testpls/hls1.m3u8"))
+	parser.Parse()
+
+	if !parser.IsMaster() {
+		t.Fatalf("Expected master playlist")
+	}
+
+	if parser.Version != 4 {
+		t.Fatalf("Expected version 4 got %d", parser.Version)
+	}
+
+	if len(parser.Variants) != 2 || len(parser.Streams) != 2 {
+		t.Fatalf("Expected 2 variants and streams got %d and %d", len(parser.Variants), len(parser.Streams))
+	}
+
+	v := parser.Variants[0]
+	if v.Bandwidth != 1280000 || v.AverageBandwidth != 1000000 || v.Codecs != "avc1.4d401f,mp4a.40.2" ||
+		v.Resolution != "640x360" || v.Audio != "aac" || v.Url != "http://live.example.com/low.m3u8" {
+		t.Fatalf("Unexpected variant %+v", v)
+	}
+
+	if parser.Streams[1].Index != 2 || parser.Streams[1].Url != "http://live.example.com/high.m3u8" {
+		t.Fatalf("Unexpected stream %+v", parser.Streams[1])
+	}
+
+	if len(parser.Renditions) != 1 {
+		t.Fatalf("Expected 1 rendition got %d", len(parser.Renditions))
+	}
+
+	r := parser.Renditions[0]
+	if r.Type != "AUDIO" || r.GroupId != "aac" || r.Name != "English" || r.Language != "en" ||
+		!r.Default || !r.Autoselect || r.Url != "audio/en.m3u8" {
+		t.Fatalf("Unexpected rendition %+v", r)
+	}
+}
+
+func TestHlsRenditionUrl(t *testing.T) {
+
+	parser := NewHlsParser(getPLFile("./testpls/hls1.m3u8"))
+	parser.SetBaseUrl("http://live.example.com/master.m3u8")
+	parser.Parse()
+
+	if len(parser.Renditions) != 1 || parser.Renditions[0].Url != "http://live.example.com/audio/en.m3u8" {
+		t.Fatalf("Expected rendition URL resolved against playlist location got %+v", parser.Renditions)
+	}
+}
+
+func TestHlsMedia(t *testing.T) {
+
+	parser := NewHlsParser(getPLFile("./testpls/hls2.m3u8"))
+	parser.Parse()
+
+	if parser.IsMaster() {
+		t.Fatalf("Expected media playlist")
+	}
+
+	if parser.TargetDuration != 10*time.Second {
+		t.Fatalf("Expected target duration 10s got %v", parser.TargetDuration)
+	}
+
+	if parser.MediaSequence != 2680 || !parser.EndList {
+		t.Fatalf("Expected media sequence 2680 and end list got %d and %v", parser.MediaSequence, parser.EndList)
+	}
+
+	if len(parser.Segments) != 3 || len(parser.Streams) != 3 {
+		t.Fatalf("Expected 3 segments and streams got %d and %d", len(parser.Segments), len(parser.Streams))
+	}
+
+	s := parser.Segments[0]
+	if s.Sequence != 2680 || s.Duration != 9009*time.Millisecond || s.Title != "First segment" {
+		t.Fatalf("Unexpected segment %+v", s)
+	}
+
+	if parser.Segments[2].Sequence != 2682 {
+		t.Fatalf("Expected sequence 2682 got %d", parser.Segments[2].Sequence)
+	}
+
+	if parser.Streams[0].Title != "First segment" || parser.Streams[0].Duration != 9009*time.Millisecond {
+		t.Fatalf("Unexpected stream %+v", parser.Streams[0])
+	}
+}
+
+func BenchmarkHlsParsing(b *testing.B) {
+
+	testFile := getPLFile("./testpls/hls1.m3u8")
+
+	for i := 0; i < b.N; i++ {
+		parser := NewHlsParser(testFile)
+		parser.Parse()
+	}
+}
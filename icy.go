@@ -0,0 +1,275 @@
+// Part of the Go playlist parser package
+//
+// Copyright 2013 Rafal Zajac rzajac<at>gmail<dot>com. All rights reserved.
+// http://github.com/rzajac/plparser
+//
+// Licensed under the MIT license
+
+package plparser
+
+import (
+	"bufio"
+	"context"
+	"crypto/tls"
+	"fmt"
+	"io"
+	"net"
+	"net/textproto"
+	"net/url"
+	"strconv"
+	"strings"
+)
+
+// icyMaxMetaBlocks is the maximum number of metadata blocks read
+// while looking for a non empty one.
+const icyMaxMetaBlocks = 3
+
+// IcyInfo holds information returned by ICY (Shoutcast / Icecast) server.
+type IcyInfo struct {
+	StatusCode  int               `json:"status"`
+	Name        string            `json:"name"`
+	Genre       string            `json:"genre"`
+	Url         string            `json:"url"`
+	Bitrate     int               `json:"bitrate"`
+	Description string            `json:"descr"`
+	MetaInt     int               `json:"metaint"`
+	ContentType string            `json:"content_type"`
+	StreamTitle string            `json:"stream_title"`
+	Metadata    map[string]string `json:"metadata,omitempty"`
+}
+
+// IcyClient talks to Shoutcast v1 and compatible servers which respond
+// with ICY status line not understood by net/http.
+type IcyClient struct {
+	// Dialer is used to open connections.
+	Dialer *net.Dialer
+	// UserAgent is sent with every request. Empty means DefaultUserAgent.
+	UserAgent string
+}
+
+// NewIcyClient returns new ICY client.
+func NewIcyClient() *IcyClient {
+	c := new(IcyClient)
+	c.Dialer = new(net.Dialer)
+	return c
+}
+
+// Probe connects to the stream, requests in-band metadata and returns
+// stream information together with the first StreamTitle.
+// The ctx controls deadline and cancellation of the whole probe.
+func (c *IcyClient) Probe(ctx context.Context, rawurl string) (*IcyInfo, error) {
+
+	u, err := url.Parse(rawurl)
+	if err != nil {
+		return nil, err
+	}
+
+	conn, err := c.dial(ctx, u)
+	if err != nil {
+		return nil, err
+	}
+	defer conn.Close()
+
+	// Make sure blocking reads return when ctx is done
+	if deadline, ok := ctx.Deadline(); ok {
+		conn.SetDeadline(deadline)
+	}
+
+	done := make(chan struct{})
+	defer close(done)
+
+	go func() {
+		select {
+		case <-ctx.Done():
+			conn.Close()
+		case <-done:
+		}
+	}()
+
+	info, err := c.probe(conn, u)
+	if err != nil && ctx.Err() != nil {
+		return nil, fetchError(ctx, ctx.Err())
+	}
+
+	return info, err
+}
+
+// ProbeStream probes stream URLs in order returned by Stream.Urls until
+// one of them succeeds. Returns information from the first working URL
+// together with the URL.
+func (c *IcyClient) ProbeStream(ctx context.Context, s *Stream) (*IcyInfo, string, error) {
+
+	var info *IcyInfo
+
+	u, err := TryStream(ctx, s, func(ctx context.Context, url string) error {
+		var err error
+		info, err = c.Probe(ctx, url)
+		return err
+	})
+
+	if err != nil {
+		return nil, "", err
+	}
+
+	return info, u, nil
+}
+
+// dial opens connection to the host from URL.
+func (c *IcyClient) dial(ctx context.Context, u *url.URL) (net.Conn, error) {
+
+	dialer := c.Dialer
+	if dialer == nil {
+		dialer = new(net.Dialer)
+	}
+
+	host := u.Host
+	if u.Port() == "" {
+		if u.Scheme == "https" {
+			host = net.JoinHostPort(u.Hostname(), "443")
+		} else {
+			host = net.JoinHostPort(u.Hostname(), "80")
+		}
+	}
+
+	switch u.Scheme {
+	case "http", "icy", "icyx":
+		return dialer.DialContext(ctx, "tcp", host)
+
+	case "https":
+		conn, err := dialer.DialContext(ctx, "tcp", host)
+		if err != nil {
+			return nil, err
+		}
+
+		tlsConn := tls.Client(conn, &tls.Config{ServerName: u.Hostname()})
+		if err = tlsConn.HandshakeContext(ctx); err != nil {
+			conn.Close()
+			return nil, err
+		}
+
+		return tlsConn, nil
+	}
+
+	return nil, newError(ErrUnsupportedScheme, u.Scheme, nil)
+}
+
+// probe sends the request and reads the response from conn.
+func (c *IcyClient) probe(conn net.Conn, u *url.URL) (*IcyInfo, error) {
+
+	userAgent := c.UserAgent
+	if userAgent == "" {
+		userAgent = DefaultUserAgent
+	}
+
+	path := u.RequestURI()
+
+	_, err := fmt.Fprintf(conn, "GET %s HTTP/1.0\r\nHost: %s\r\nUser-Agent: %s\r\nIcy-MetaData: 1\r\nAccept: */*\r\n\r\n",
+		path, u.Host, userAgent)
+	if err != nil {
+		return nil, err
+	}
+
+	reader := bufio.NewReader(conn)
+	tp := textproto.NewReader(reader)
+
+	// Status line is either ICY 200 OK or HTTP/1.x 200 OK
+	status, err := tp.ReadLine()
+	if err != nil {
+		return nil, err
+	}
+
+	info := new(IcyInfo)
+
+	fields := strings.Fields(status)
+	if len(fields) < 2 || !(fields[0] == "ICY" || strings.HasPrefix(fields[0], "HTTP/")) {
+		return nil, newError(ErrMalformedResponse, "ICY status line "+status, nil)
+	}
+
+	if info.StatusCode, err = strconv.Atoi(fields[1]); err != nil {
+		return nil, newError(ErrMalformedResponse, "ICY status line "+status, nil)
+	}
+
+	header, err := tp.ReadMIMEHeader()
+	if err != nil && len(header) == 0 {
+		return nil, err
+	}
+
+	info.Name = header.Get("Icy-Name")
+	info.Genre = header.Get("Icy-Genre")
+	info.Url = header.Get("Icy-Url")
+	info.Description = header.Get("Icy-Description")
+	info.ContentType = header.Get("Content-Type")
+	info.Bitrate, _ = strconv.Atoi(strings.TrimSpace(strings.SplitN(header.Get("Icy-Br"), ",", 2)[0]))
+	info.MetaInt, _ = strconv.Atoi(header.Get("Icy-Metaint"))
+
+	if info.StatusCode != 200 || info.MetaInt <= 0 {
+		return info, nil
+	}
+
+	for i := 0; i < icyMaxMetaBlocks; i++ {
+
+		meta, err := readIcyMetadata(reader, info.MetaInt)
+		if err != nil {
+			return info, err
+		}
+
+		if meta == "" {
+			continue
+		}
+
+		info.Metadata = parseIcyMetadata(meta)
+		info.StreamTitle = info.Metadata["StreamTitle"]
+		break
+	}
+
+	return info, nil
+}
+
+// readIcyMetadata skips metaint bytes of audio and reads metadata block.
+func readIcyMetadata(r *bufio.Reader, metaint int) (string, error) {
+
+	if _, err := r.Discard(metaint); err != nil {
+		return "", err
+	}
+
+	length, err := r.ReadByte()
+	if err != nil {
+		return "", err
+	}
+
+	block := make([]byte, int(length)*16)
+	if _, err = io.ReadFull(r, block); err != nil {
+		return "", err
+	}
+
+	return strings.TrimRight(string(block), "\x00"), nil
+}
+
+// parseIcyMetadata parses metadata block in form of Key='value';Key2='value2';
+// Values may contain single quotes and semicolons.
+func parseIcyMetadata(meta string) map[string]string {
+
+	values := make(map[string]string, 2)
+
+	for meta != "" {
+
+		eq := strings.Index(meta, "='")
+		if eq == -1 {
+			break
+		}
+
+		key := strings.TrimSpace(meta[:eq])
+		meta = meta[eq+2:]
+
+		end := strings.Index(meta, "';")
+		if end == -1 {
+			values[key] = strings.TrimSuffix(meta, "'")
+			break
+		}
+
+		values[key] = meta[:end]
+		meta = meta[end+2:]
+	}
+
+	return values
+}
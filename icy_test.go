@@ -0,0 +1,170 @@
+// Part of the Go playlist parser package
+//
+// Copyright 2013 Rafal Zajac rzajac<at>gmail<dot>com. All rights reserved.
+// http://github.com/rzajac/plparser
+//
+// Licensed under the MIT license
+
+package plparser
+
+import (
+	"bufio"
+	"context"
+	"io"
+	"io/ioutil"
+	"net"
+	"net/textproto"
+	"strings"
+	"testing"
+	"time"
+)
+
+// startIcyServer starts fake Shoutcast v1 server responding with resp
+// to every request. Returns server address and channel with request headers.
+func startIcyServer(t *testing.T, resp string) (string, chan textproto.MIMEHeader) {
+
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("Can not listen: %s", err)
+	}
+
+	headers := make(chan textproto.MIMEHeader, 1)
+
+	go func() {
+		defer ln.Close()
+
+		conn, err := ln.Accept()
+		if err != nil {
+			return
+		}
+		defer conn.Close()
+
+		tp := textproto.NewReader(bufio.NewReader(conn))
+		tp.ReadLine()
+		header, _ := tp.ReadMIMEHeader()
+		headers <- header
+
+		conn.Write([]byte(resp))
+
+		// Keep connection open until client closes it
+		io.Copy(ioutil.Discard, conn)
+	}()
+
+	return ln.Addr().String(), headers
+}
+
+// icyMetaBlock returns ICY metadata block with length byte.
+func icyMetaBlock(meta string) string {
+	length := (len(meta) + 15) / 16
+	return string([]byte{byte(length)}) + meta + strings.Repeat("\x00", length*16-len(meta))
+}
+
+func TestIcyProbe(t *testing.T) {
+
+	resp := "ICY 200 OK\r\n" +
+		"icy-name:Radio One\r\n" +
+		"icy-genre:Rock\r\n" +
+		"icy-url:http://radio.example.com\r\n" +
+		"icy-br:128\r\n" +
+		"icy-description:Best rock\r\n" +
+		"icy-metaint:16\r\n" +
+		"content-type:audio/mpeg\r\n" +
+		"\r\n" +
+		strings.Repeat("A", 16) + icyMetaBlock("") +
+		strings.Repeat("A", 16) + icyMetaBlock("StreamTitle='Artist - Don't stop; go';StreamUrl='';")
+
+	addr, headers := startIcyServer(t, resp)
+
+	info, err := NewIcyClient().Probe(context.Background(), "http://"+addr+"/stream")
+	if err != nil {
+		t.Fatalf("Unexpected error %s", err)
+	}
+
+	header := <-headers
+	if header.Get("Icy-MetaData") != "1" {
+		t.Fatalf("Expected Icy-MetaData header to be sent")
+	}
+
+	if info.StatusCode != 200 || info.Name != "Radio One" || info.Genre != "Rock" || info.Url != "http://radio.example.com" ||
+		info.Bitrate != 128 || info.Description != "Best rock" || info.MetaInt != 16 || info.ContentType != "audio/mpeg" {
+		t.Fatalf("Unexpected info %+v", info)
+	}
+
+	if info.StreamTitle != "Artist - Don't stop; go" {
+		t.Fatalf("Expected StreamTitle 'Artist - Don't stop; go' got '%s'", info.StreamTitle)
+	}
+}
+
+func TestIcyProbeNoMetadata(t *testing.T) {
+
+	addr, _ := startIcyServer(t, "HTTP/1.0 200 OK\r\nicy-name:Radio Two\r\nicy-br:64, 64\r\n\r\nAAAA")
+
+	info, err := NewIcyClient().Probe(context.Background(), "http://"+addr+"/")
+	if err != nil {
+		t.Fatalf("Unexpected error %s", err)
+	}
+
+	if info.Name != "Radio Two" || info.Bitrate != 64 || info.StreamTitle != "" {
+		t.Fatalf("Unexpected info %+v", info)
+	}
+}
+
+func TestIcyProbeStream(t *testing.T) {
+
+	addr, _ := startIcyServer(t, "HTTP/1.0 200 OK\r\nicy-name:Radio Two\r\n\r\nAAAA")
+
+	// Address with nothing listening on it
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("Can not listen: %s", err)
+	}
+	down := ln.Addr().String()
+	ln.Close()
+
+	s := NewStream(1)
+	s.Url = "http://" + down + "/"
+	s.Fallbacks = []string{"http://" + addr + "/"}
+
+	info, u, err := NewIcyClient().ProbeStream(context.Background(), s)
+	if err != nil {
+		t.Fatalf("Unexpected error %s", err)
+	}
+
+	if info.Name != "Radio Two" || u != s.Fallbacks[0] {
+		t.Fatalf("Expected fallback %s to be probed got %s %+v", s.Fallbacks[0], u, info)
+	}
+}
+
+func TestIcyProbeTimeout(t *testing.T) {
+
+	// Server never finishes headers
+	addr, _ := startIcyServer(t, "ICY 200 OK\r\n")
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+
+	if _, err := NewIcyClient().Probe(ctx, "http://"+addr+"/"); err == nil {
+		t.Fatalf("Expected timeout error")
+	}
+}
+
+func TestIcyParseMetadata(t *testing.T) {
+
+	var tests = []struct {
+		meta     string
+		key      string
+		expected string
+	}{
+		{"StreamTitle='Title';", "StreamTitle", "Title"},
+		{"StreamTitle='';StreamUrl='http://ex.com';", "StreamUrl", "http://ex.com"},
+		{"StreamTitle='It's';", "StreamTitle", "It's"},
+		{"StreamTitle='No end'", "StreamTitle", "No end"},
+		{"", "StreamTitle", ""},
+	}
+
+	for _, test := range tests {
+		if v := parseIcyMetadata(test.meta)[test.key]; v != test.expected {
+			t.Fatalf("Expected %s '%s' == '%s' for '%s'", test.key, test.expected, v, test.meta)
+		}
+	}
+}
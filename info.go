@@ -0,0 +1,33 @@
+// Part of the Go playlist parser package
+//
+// Copyright 2013 Rafal Zajac rzajac<at>gmail<dot>com. All rights reserved.
+// http://github.com/rzajac/plparser
+//
+// Licensed under the MIT license
+
+package plparser
+
+// PlaylistInfo holds playlist level metadata found in the playlist header.
+// Fields not present in a playlist format are empty.
+type PlaylistInfo struct {
+	Title       string `json:"title,omitempty"`
+	Author      string `json:"author,omitempty"`
+	Copyright   string `json:"copyright,omitempty"`
+	Description string `json:"descr,omitempty"`
+	Logo        string `json:"logo,omitempty"`
+	MoreInfo    string `json:"info,omitempty"`
+	Base        string `json:"base,omitempty"`    // Base URL for relative stream URLs
+	Version     string `json:"version,omitempty"` // Format version declared in the playlist
+	Entries     int    `json:"entries,omitempty"` // Number of entries declared in the playlist
+
+	// Attributes holds additional key value pairs of the playlist
+	// header for example ASX PARAM elements.
+	Attributes map[string]string `json:"attrs,omitempty"`
+}
+
+// InfoGetter is implemented by parsers which collect playlist level
+// metadata. All built in parsers implement it.
+type InfoGetter interface {
+	// GetInfo gets playlist level metadata. Must be called after Parse.
+	GetInfo() *PlaylistInfo
+}
@@ -0,0 +1,98 @@
+// Part of the Go playlist parser package
+//
+// Copyright 2013 Rafal Zajac rzajac<at>gmail<dot>com. All rights reserved.
+// http://github.com/rzajac/plparser
+//
+// Licensed under the MIT license
+
+package plparser
+
+import (
+	"encoding/json"
+	"reflect"
+	"testing"
+)
+
+func TestPlaylistInfo(t *testing.T) {
+
+	var tests = map[string]PlaylistInfo{
+		"./testpls/asx1.asx":   {Title: "MT", Author: "MAU", Copyright: "MC", Description: "MA", Logo: "http://ml.ex.com/l.gif", MoreInfo: "http://mi.ex.com/mi", Version: "3.0"},
+		"./testpls/xspf1.xspf": {Title: "MT", Author: "MAU", Description: "MA", Logo: "http://ml.ex.com/l.gif", MoreInfo: "http://mi.ex.com/mi", Version: "1"},
+		"./testpls/pls1.pls":   {Entries: 5},
+		"./testpls/hls1.m3u8":  {Version: "4"},
+		"./testpls/m3u1.m3u":   {},
+		"./testpls/asf1.asf":   {},
+	}
+
+	for filePath, expected := range tests {
+
+		plr := new(PlaylistResp)
+		plr.Raw = getPLFile(filePath)
+
+		pl := NewPlaylist(plr)
+		if _, err := pl.Parse(); err != nil {
+			t.Fatalf("Unexpected error %s (%s)", err, filePath)
+		}
+
+		if pl.Info == nil || !reflect.DeepEqual(*pl.Info, expected) {
+			t.Fatalf("Expected info %+v got %+v (%s)", expected, pl.Info, filePath)
+		}
+	}
+}
+
+func TestPlaylistInfoHeaders(t *testing.T) {
+
+	m3u := NewM3uParser([]byte("#EXTM3U\n#PLAYLIST: Radio Example\n#EXTINF:-1,One\nhttp://ex.com/\n"))
+	m3u.Parse()
+
+	if info := m3u.GetInfo(); info.Title != "Radio Example" {
+		t.Fatalf("Expected M3U title Radio Example got %s", info.Title)
+	}
+
+	pls := NewPlsParser([]byte("[playlist]\nFile1=http://ex.com/\nNumberOfEntries=1\nVersion=2\n"))
+	pls.Parse()
+
+	if info := pls.GetInfo(); info.Version != "2" || info.Entries != 1 {
+		t.Fatalf("Expected PLS version 2 and 1 entry got %+v", info)
+	}
+
+	asx := NewAsxParser([]byte("<asx version=\"3.0\">\n<base href=\"http://ex.com/media/\"/>\n<entry><ref href=\"a.mp3\"/></entry>\n</asx>"))
+	asx.Parse()
+
+	if info := asx.GetInfo(); info.Base != "http://ex.com/media/" || info.Version != "3.0" {
+		t.Fatalf("Expected ASX base and version got %+v", info)
+	}
+
+	asx = NewAsxParser([]byte("<asx version=\"3.0\">\n<param name=\"Genre\" value=\"Jazz\"/>\n<entry><ref href=\"http://ex.com/a.mp3\"/></entry>\n</asx>"))
+	asx.Parse()
+
+	if info := asx.GetInfo(); info.Attributes["Genre"] != "Jazz" {
+		t.Fatalf("Expected ASX header attributes got %+v", info)
+	}
+}
+
+func TestPlaylistInfoJson(t *testing.T) {
+
+	plr := new(PlaylistResp)
+	plr.Raw = getPLFile("./testpls/xspf1.xspf")
+
+	pl := NewPlaylist(plr)
+	pl.Parse()
+
+	j, err := pl.StreamsAsJson()
+	if err != nil {
+		t.Fatalf("Unexpected error %s", err)
+	}
+
+	var decoded struct {
+		Info PlaylistInfo `json:"info"`
+	}
+
+	if err = json.Unmarshal([]byte(j), &decoded); err != nil {
+		t.Fatalf("Unexpected error %s", err)
+	}
+
+	if decoded.Info.Title != "MT" || decoded.Info.Author != "MAU" {
+		t.Fatalf("Expected playlist info in JSON got %s", j)
+	}
+}
@@ -11,12 +11,30 @@ import (
 	"bufio"
 	"bytes"
 	"io"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
 )
 
+func init() {
+	RegisterFormat(&Format{
+		Name:       "m3u",
+		MimeTypes:  []string{"audio/mpegurl", "audio/x-mpegurl"},
+		Extensions: []string{".m3u"},
+		Detect:     detectM3u,
+		Plausible:  plausibleM3u,
+		New:        func(raw []byte) Playlister { return NewM3uParser(raw) },
+	})
+}
+
 // M3uParser implements M3U playlist parser.
 type M3uParser struct {
+	Diagnostics
+	UrlResolver
 	raw     []byte
 	reader  *bufio.Reader
+	Title   string // Value of #PLAYLIST directive
 	Streams []*Stream
 }
 
@@ -32,32 +50,218 @@ func NewM3uParser(raw []byte) *M3uParser {
 }
 
 // Parse parses a M3U playlist.
-func (p *M3uParser) Parse() {
-	var idx int
+func (p *M3uParser) Parse() error {
+	var idx, lineNo int
+
+	// Stream info from #EXTINF describing the URL on the next line
+	var extinf *Stream
+	var extinfLine int
+	var extinfText string
 
 	for {
 		line, err := p.reader.ReadString('\n')
+		lineNo++
 
 		if err != nil && err != io.EOF {
-			break
+			p.AddDiagnostic(lineNo, 0, SeverityError, RULE_READ_ERROR, "Read error: "+err.Error(), "")
+			return &ParseError{Line: lineNo, Err: err}
 		}
 
 		line = fixString(line)
 
-		if isUrl(line) {
+		if strings.HasPrefix(strings.ToUpper(line), "#EXTINF:") {
+			if extinf != nil {
+				p.AddDiagnostic(extinfLine, 1, SeverityWarning, RULE_EXTINF_WITHOUT_URI, "#EXTINF without following URL", extinfText)
+			}
+
+			extinf = NewStream(0)
+			extinf.Duration, extinf.Title, extinf.Attributes = parseExtinf(line[len("#EXTINF:"):])
+			extinfLine, extinfText = lineNo, line
+		}
+
+		if strings.HasPrefix(strings.ToUpper(line), "#PLAYLIST:") {
+			p.Title = strings.TrimSpace(line[len("#PLAYLIST:"):])
+		}
+
+		// Every line which is not a comment is a stream reference
+		if line != "" && !strings.HasPrefix(line, "#") {
 			idx += 1
 			stream := NewStream(idx)
-			stream.Url = line
-			p.Streams = append(p.Streams, stream)
+
+			if extinf != nil {
+				stream = extinf.makeCopy()
+				stream.Index = idx
+				extinf = nil
+			}
+
+			stream.OriginalUrl = line
+			stream.Url = p.resolveUrl(line)
+
+			if p.checkStream(&p.Diagnostics, stream, lineNo, 1, line) {
+				p.Streams = append(p.Streams, stream)
+			}
 		}
 
 		if err == io.EOF {
 			break
 		}
 	}
+
+	if extinf != nil {
+		p.AddDiagnostic(extinfLine, 1, SeverityWarning, RULE_EXTINF_WITHOUT_URI, "#EXTINF without following URL", extinfText)
+	}
+
+	return nil
 }
 
 // GetStreams gets list of found streams in the playlist.
 func (p *M3uParser) GetStreams() []*Stream {
 	return p.Streams
 }
+
+// GetInfo gets playlist level metadata.
+func (p *M3uParser) GetInfo() *PlaylistInfo {
+	return &PlaylistInfo{Title: p.Title}
+}
+
+// parseExtinf parses value of #EXTINF tag in form of
+// <duration> key="value" key2="value2",<title>.
+func parseExtinf(text string) (duration time.Duration, title string, attrs map[string]string) {
+
+	// Find the comma separating title ignoring commas in quoted values
+	quoted := false
+	comma := -1
+
+	for i := 0; i < len(text) && comma == -1; i++ {
+		switch text[i] {
+		case '"':
+			quoted = !quoted
+		case ',':
+			if !quoted {
+				comma = i
+			}
+		}
+	}
+
+	if comma != -1 {
+		title = strings.TrimSpace(text[comma+1:])
+		text = text[:comma]
+	}
+
+	// Duration is everything up to the first white space
+	text = strings.TrimSpace(text)
+	durText := text
+	if space := strings.IndexAny(text, " \t"); space != -1 {
+		durText, text = text[:space], text[space+1:]
+	} else {
+		text = ""
+	}
+
+	if secs, err := strconv.ParseFloat(durText, 64); err == nil {
+		if secs < 0 {
+			duration = LiveDuration
+		} else {
+			duration = time.Duration(secs * float64(time.Second))
+		}
+	}
+
+	attrs = parseExtinfAttributes(text)
+
+	return
+}
+
+// parseExtinfAttributes parses white space separated key="value" pairs.
+func parseExtinfAttributes(text string) map[string]string {
+
+	var attrs map[string]string
+
+	for {
+		text = strings.TrimSpace(text)
+
+		eq := strings.IndexByte(text, '=')
+		if eq == -1 {
+			break
+		}
+
+		key := strings.ToLower(strings.TrimSpace(text[:eq]))
+		text = strings.TrimSpace(text[eq+1:])
+
+		var value string
+
+		if strings.HasPrefix(text, "\"") {
+			end := strings.IndexByte(text[1:], '"')
+			if end == -1 {
+				value, text = text[1:], ""
+			} else {
+				value, text = text[1:end+1], text[end+2:]
+			}
+		} else {
+			end := strings.IndexAny(text, " \t")
+			if end == -1 {
+				value, text = text, ""
+			} else {
+				value, text = text[:end], text[end:]
+			}
+		}
+
+		if key == "" {
+			continue
+		}
+
+		if attrs == nil {
+			attrs = make(map[string]string, 5)
+		}
+
+		attrs[key] = value
+	}
+
+	return attrs
+}
+
+// detectM3u returns true if playlist is a M3U playlist.
+func detectM3u(header string, raw []byte) bool {
+	return isM3uHeader(header) && !isHls(raw)
+}
+
+// m3uPathReg matches lines looking like a file name with an extension.
+var m3uPathReg = regexp.MustCompile(`\.[A-Za-z0-9]{2,4}$`)
+
+// plausibleM3u returns true if playlist could be M3U playlist with only
+// relative stream references: every not comment line looks like a path.
+func plausibleM3u(header string, raw []byte) bool {
+
+	if bytes.IndexByte(raw, 0) != -1 || isHls(raw) {
+		return false
+	}
+
+	var paths int
+
+	for _, line := range strings.Split(string(raw), "\n") {
+
+		line = fixString(line)
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+
+		if strings.HasPrefix(line, "<") || strings.HasPrefix(line, "[") || strings.HasPrefix(line, "{") {
+			return false
+		}
+
+		if !strings.ContainsAny(line, "/\\") && !m3uPathReg.MatchString(line) {
+			return false
+		}
+
+		if paths++; paths == DefaultDetectLines {
+			break
+		}
+	}
+
+	return paths > 0
+}
+
+// isM3uHeader returns true if header is the first line of M3U playlist.
+func isM3uHeader(header string) bool {
+	return strings.HasPrefix(header, "http") ||
+		strings.HasPrefix(header, "#extm3u") ||
+		strings.HasPrefix(header, "#extinf")
+}
@@ -8,7 +8,9 @@
 package plparser
 
 import (
+	"reflect"
 	"testing"
+	"time"
 )
 
 func TestM3uRegExp(t *testing.T) {
@@ -47,9 +49,17 @@ func TestM3uFiles(t *testing.T) {
 		3: {3, "", "", "", "", "", "", "http://live3.example.com:2153/"},
 	}
 
+	var m3u3 = map[int]plTestStruct{
+		0: {},
+		1: {1, "Radio One", "", "", "", "", "", "http://live1.example.com:2151/"},
+		2: {2, "Artist - Track, remastered", "", "", "", "", "", "http://live2.example.com:2152/track.mp3"},
+		3: {3, "", "", "", "", "", "", "http://live3.example.com:2153/"},
+	}
+
 	var testFiles = map[string]map[int]plTestStruct{
 		"./testpls/m3u1.m3u": m3u1,
 		"./testpls/m3u2.m3u": m3u2,
+		"./testpls/m3u3.m3u": m3u3,
 	}
 
 	for filePath, rulez := range testFiles {
@@ -58,6 +68,72 @@ func TestM3uFiles(t *testing.T) {
 	}
 }
 
+func TestM3uExtinf(t *testing.T) {
+
+	var testLines = []struct {
+		line     string
+		duration time.Duration
+		title    string
+		attrs    map[string]string
+	}{
+		{"-1,Title", LiveDuration, "Title", nil},
+		{"0,Title", 0, "Title", nil},
+		{"10.5,", 10500 * time.Millisecond, "", nil},
+		{"123", 123 * time.Second, "", nil},
+		{`-1 tvg-id="a.b" group-title="News, Talk",Title, with comma`, LiveDuration, "Title, with comma",
+			map[string]string{"tvg-id": "a.b", "group-title": "News, Talk"}},
+		{`-1 TVG-NAME="Name" radio=true,Title`, LiveDuration, "Title",
+			map[string]string{"tvg-name": "Name", "radio": "true"}},
+	}
+
+	for _, testLine := range testLines {
+		duration, title, attrs := parseExtinf(testLine.line)
+
+		if duration != testLine.duration {
+			t.Fatalf("Expected duration %v == %v for '%s'", testLine.duration, duration, testLine.line)
+		}
+
+		if title != testLine.title {
+			t.Fatalf("Expected title '%s' == '%s' for '%s'", testLine.title, title, testLine.line)
+		}
+
+		if !reflect.DeepEqual(attrs, testLine.attrs) {
+			t.Fatalf("Expected attributes %v == %v for '%s'", testLine.attrs, attrs, testLine.line)
+		}
+	}
+}
+
+func TestM3uExtinfStreams(t *testing.T) {
+
+	parser := NewM3uParser(getPLFile("./testpls/m3u3.m3u"))
+	parser.Parse()
+
+	s := parser.Streams[0]
+	if s.Duration != LiveDuration {
+		t.Fatalf("Expected live duration got %v", s.Duration)
+	}
+
+	var expected = map[string]string{
+		"tvg-id":      "radio1.pl",
+		"tvg-name":    "Radio One",
+		"tvg-logo":    "http://logo.example.com/r1.png",
+		"group-title": "News, Talk",
+		"radio":       "true",
+	}
+
+	if !reflect.DeepEqual(s.Attributes, expected) {
+		t.Fatalf("Expected attributes %v == %v", expected, s.Attributes)
+	}
+
+	if parser.Streams[1].Duration != 215*time.Second || parser.Streams[1].Attributes != nil {
+		t.Fatalf("Unexpected stream %+v", parser.Streams[1])
+	}
+
+	if parser.Streams[2].Duration != 0 || parser.Streams[2].Title != "" {
+		t.Fatalf("Expected #EXTINF not to leak to the next stream %+v", parser.Streams[2])
+	}
+}
+
 func testM3uFile(filePath string, rulez map[int]plTestStruct, t *testing.T) {
 
 	rawPlaylist := getPLFile(filePath)
@@ -13,7 +13,6 @@ import (
 	"encoding/json"
 	// "fmt"
 	"io"
-	"strings"
 )
 
 // Audio formats.
@@ -28,10 +27,24 @@ var (
 
 // Playlister is an interface all playlist parsers must implement.
 type Playlister interface {
-	// Parse parses a playlist.
-	Parse()
-	// GetStreams gets list of streams in a playlist.
+	// Parse parses a playlist. Returns error only if the playlist
+	// could not be parsed at all, problems with individual lines
+	// or elements are reported as diagnostics.
+	Parse() error
+	// GetStreams gets list of streams in a playlist. The order must be
+	// the same every time the playlist is parsed: by index for formats
+	// with indexed entries (PLS, ASF) and document order for others.
 	GetStreams() []*Stream
+	// GetDiagnostics gets list of diagnostics collected during parsing.
+	GetDiagnostics() []*Diagnostic
+}
+
+// OrderPreserver is implemented by parsers of formats with indexed entries
+// which by default order streams by index.
+type OrderPreserver interface {
+	// SetPreserveOrder makes parser keep streams in file order.
+	// Must be called before Parse.
+	SetPreserveOrder(preserve bool)
 }
 
 // NewPlaylist creates new playlist based on PlaylistResponse.
@@ -48,9 +61,29 @@ func NewPlaylist(plr *PlaylistResp) *Playlist {
 
 // Playlist the playlist.
 type Playlist struct {
-	Type    string        `json:"type"`
-	Streams []*Stream     `json:"streams"`
-	Resp    *PlaylistResp `json:"-"`
+	Type        string        `json:"type"`
+	Charset     string        `json:"charset,omitempty"` // Charset the playlist was transcoded from
+	Info        *PlaylistInfo `json:"info,omitempty"`    // Playlist level metadata
+	Streams     []*Stream     `json:"streams"`
+	Diagnostics []*Diagnostic `json:"diagnostics,omitempty"`
+	Resp        *PlaylistResp `json:"-"`
+	Parser      Playlister    `json:"-"` // Parser used, gives access to format specific data
+
+	// SchemePolicy decides which stream URL schemes are accepted.
+	// Nil means the default policy. Must be set before Parse.
+	SchemePolicy *SchemePolicy `json:"-"`
+
+	// PreserveOrder keeps streams of formats with indexed entries (PLS, ASF)
+	// in file order instead of index order. Must be set before Parse.
+	PreserveOrder bool `json:"-"`
+
+	// GroupMirrors groups PLS entries which differ only by server to one
+	// stream with Fallbacks. Must be set before Parse.
+	GroupMirrors bool `json:"-"`
+
+	// AsxLoader loads playlists referenced by ASX ENTRYREF elements.
+	// Nil means ENTRYREF elements are not followed. Must be set before Parse.
+	AsxLoader AsxLoader `json:"-"`
 
 	firstLine  string        `json:"-"`
 	lineReader *bufio.Reader `json:"-"`
@@ -60,6 +93,10 @@ func (p *Playlist) Parse() (string, error) {
 
 	var err error
 
+	if p.Charset == "" {
+		p.transcode()
+	}
+
 	// Get first line that is not empty
 	// We use the first not empty line of the playlist
 	// to detect playlist type
@@ -70,51 +107,67 @@ func (p *Playlist) Parse() (string, error) {
 
 	// Detect playlist and parse it
 	if p.detectType() {
-		var parser Playlister
-
-		switch p.Type {
-		case "pls":
-			parser = NewPlsParser(p.Resp.Raw)
-		case "asf":
-			parser = NewAsfParser(p.Resp.Raw)
-		case "asx":
-			parser = NewAsxParser(p.Resp.Raw)
-		case "m3u":
-			parser = NewM3uParser(p.Resp.Raw)
-		}
+		if format := LookupFormat(p.Type); format != nil {
+			parser := format.New(p.Resp.Raw)
+
+			// Relative stream references are resolved against playlist location
+			if bs, ok := parser.(BaseUrlSetter); ok {
+				bs.SetBaseUrl(p.Resp.Url)
+			}
+
+			if sps, ok := parser.(SchemePolicySetter); ok {
+				sps.SetSchemePolicy(p.SchemePolicy)
+			}
 
-		if parser != nil {
-			parser.Parse()
+			if op, ok := parser.(OrderPreserver); ok {
+				op.SetPreserveOrder(p.PreserveOrder)
+			}
+
+			if mg, ok := parser.(MirrorGrouper); ok {
+				mg.SetGroupMirrors(p.GroupMirrors)
+			}
+
+			if ls, ok := parser.(AsxLoaderSetter); ok {
+				ls.SetAsxLoader(p.AsxLoader)
+			}
+
+			err = parser.Parse()
 			p.Streams = parser.GetStreams()
+			p.Diagnostics = parser.GetDiagnostics()
+			p.Parser = parser
+
+			if ig, ok := parser.(InfoGetter); ok {
+				p.Info = ig.GetInfo()
+			}
 		}
 	}
 
 	return p.Type, err
 }
 
-// detectType detects playlist type based on its first not empty line.
-func (p *Playlist) detectType() bool {
+// transcode detects playlist charset and transcodes raw response to UTF-8.
+func (p *Playlist) transcode() {
 
-	header := strings.ToLower(p.firstLine)
+	p.Charset = DetectCharset(p.Resp.Raw, p.Resp.ContentType)
 
-	if header == "[playlist]" {
-		p.Type = "pls"
+	raw, err := ToUtf8(p.Resp.Raw, p.Charset)
+	if err != nil {
+		return
 	}
 
-	if header == "[reference]" {
-		p.Type = "asf"
-	}
+	// XML parsers would transcode it again
+	p.Resp.Raw = setXmlEncoding(raw, CHARSET_UTF8)
+	p.lineReader = bufio.NewReader(bytes.NewReader(p.Resp.Raw))
+}
 
-	if strings.HasPrefix(header, "<asx") {
-		p.Type = "asx"
-	}
+// detectType detects playlist type using Detector. The most probable
+// format is used if its confidence reaches DetectThreshold.
+func (p *Playlist) detectType() bool {
 
-	if strings.HasPrefix(header, "http") {
-		p.Type = "m3u"
-	}
+	candidates := DetectFormat(p.Resp)
 
-	if strings.HasPrefix(header, "#EXTM3U") || strings.HasPrefix(header, "#EXTINF") {
-		p.Type = "m3u"
+	if len(candidates) > 0 && candidates[0].Confidence >= DetectThreshold {
+		p.Type = candidates[0].Format
 	}
 
 	return p.IsDetected()
@@ -146,7 +199,7 @@ func (p *Playlist) getLine() (string, error) {
 
 		line = fixString(line)
 
-		if len(line) != 0 {
+		if len(line) != 0 || err == io.EOF {
 			break
 		}
 
@@ -163,36 +216,8 @@ func (p *Playlist) getLine() (string, error) {
 func (p *Playlist) StreamsAsJson() (string, error) {
 	j, err := json.MarshalIndent(p, " ", " ")
 	if err != nil {
-		return "", NewPlParserError(err.Error(), true)
-	}
-
-	return string(j), err
-}
-
-// PlParserError
-type PlParserError struct {
-	doJsonError bool
-	Msg         string `json:"error"`
-}
-
-// NewPlParserError creates new error.
-func NewPlParserError(msg string, doJsonError bool) *PlParserError {
-	plpe := new(PlParserError)
-	plpe.doJsonError = doJsonError
-	plpe.Msg = msg
-
-	return plpe
-}
-
-// Error returns error message.
-func (p *PlParserError) Error() (msg string) {
-
-	if p.doJsonError {
-		e, _ := json.MarshalIndent(p, " ", " ")
-		msg = string(e)
-	} else {
-		msg = p.Msg
+		return "", err
 	}
 
-	return
+	return string(j), nil
 }
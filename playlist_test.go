@@ -9,6 +9,8 @@ package plparser
 
 import (
 	// "fmt"
+	"path/filepath"
+	"reflect"
 	"testing"
 )
 
@@ -25,10 +27,16 @@ func TestPlaylist(t *testing.T) {
 		"./testpls/asx2.asx":     {"asx", true, "<ASX version=\"3.0\" BANNERBAR=\"AUTO\">"},
 		"./testpls/m3u1.m3u":     {"m3u", true, "http://live1.example.com:2151/"},
 		"./testpls/m3u2.m3u":     {"m3u", true, "http://live1.example.com:2151/"},
+		"./testpls/m3u3.m3u":     {"m3u", true, "#EXTM3U"},
+		"./testpls/m3u4.m3u":     {"m3u", true, "#EXTM3U"},
 		"./testpls/pls1.pls":     {"pls", true, "[playlist]"},
 		"./testpls/pls2.pls":     {"pls", true, "[playlist]"},
 		"./testpls/pls3.pls":     {"pls", true, "[playlist]"},
 		"./testpls/pls4.pls":     {"pls", true, "[playlist]"},
+		"./testpls/hls1.m3u8":    {"hls", true, "#EXTM3U"},
+		"./testpls/hls2.m3u8":    {"hls", true, "#EXTM3U"},
+		"./testpls/xspf1.xspf":   {"xspf", true, "<?xml version=\"1.0\" encoding=\"UTF-8\"?>"},
+		"./testpls/xspf2.xspf":   {"xspf", true, "<playlist version=\"1\" xmlns=\"http://xspf.org/ns/0/\">"},
 		"./testpls/unknown1.txt": {"", false, "Not a playlist"},
 		"./testpls/unknown2.txt": {"", false, "[WeirdPlaylist]"},
 	}
@@ -61,3 +69,58 @@ func TestPlaylist(t *testing.T) {
 	}
 
 }
+
+func TestPlaylistRelativeM3u(t *testing.T) {
+
+	plr := new(PlaylistResp)
+	plr.Url = "http://ex.com/radio/list.m3u"
+	plr.ContentType = "audio/x-mpegurl"
+	plr.Raw = []byte("stream.mp3\n/live/aac\n")
+
+	pl := NewPlaylist(plr)
+	if pltype, err := pl.Parse(); err != nil || pltype != "m3u" {
+		t.Fatalf("Expected m3u playlist got '%s' %v", pltype, err)
+	}
+
+	expected := []string{"http://ex.com/radio/stream.mp3", "http://ex.com/live/aac"}
+
+	if len(pl.Streams) != len(expected) {
+		t.Fatalf("Expected %d streams got %d", len(expected), len(pl.Streams))
+	}
+
+	for i, u := range expected {
+		if pl.Streams[i].Url != u {
+			t.Fatalf("Expected stream URL %s got %s", u, pl.Streams[i].Url)
+		}
+	}
+}
+
+func TestPlaylistStreamOrder(t *testing.T) {
+
+	files, _ := filepath.Glob("./testpls/*")
+
+	for _, filePath := range files {
+
+		var first []string
+
+		for i := 0; i < 10; i++ {
+
+			plr := new(PlaylistResp)
+			plr.Raw = getPLFile(filePath)
+
+			pl := NewPlaylist(plr)
+			pl.Parse()
+
+			urls := make([]string, 0, len(pl.Streams))
+			for _, s := range pl.Streams {
+				urls = append(urls, s.Url)
+			}
+
+			if i == 0 {
+				first = urls
+			} else if !reflect.DeepEqual(first, urls) {
+				t.Fatalf("Expected the same order of streams got %v and %v (%s)", first, urls, filePath)
+			}
+		}
+	}
+}
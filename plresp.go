@@ -8,12 +8,10 @@
 package plparser
 
 import (
-	"errors"
-	"io"
+	"context"
 	"io/ioutil"
 	"net/http"
 	"os"
-	"strings"
 	"time"
 )
 
@@ -24,32 +22,22 @@ var BINARY = map[string]bool{
 
 // Text content types.
 var TEXT = map[string]bool{
-	"text/plain":                true,
-	"text/plain; charset=utf-8": true,
-	"text/html":                 true,
-	"text/html; charset=utf-8":  true,
-	"audio/x-scpls":             true, // PLS playlist
-	"video/x-ms-asf":            true, // ASX playlist
-	"audio/mpegurl":             true, // M3U playlist
-	"audio/x-mpegurl":           true, // M3U playlist
-}
-
-// HttpResp is used to send response through typed channel.
-type HttpResp struct {
-	resp *http.Response
-	err  error
-}
-
-// getBody does HTTP GET and sends response on return channel.
-func getBody(client *http.Client, req *http.Request, retch chan *HttpResp) {
-
-	resp, err := client.Do(req)
-
-	r := new(HttpResp)
-	r.resp = resp
-	r.err = err
-
-	retch <- r
+	"text/plain":                    true,
+	"text/plain; charset=utf-8":     true,
+	"text/html":                     true,
+	"text/html; charset=utf-8":      true,
+	"audio/x-scpls":                 true, // PLS playlist
+	"video/x-ms-asf":                true, // ASX playlist
+	"audio/mpegurl":                 true, // M3U playlist
+	"audio/x-mpegurl":               true, // M3U playlist
+	"audio/scpls":                   true, // PLS playlist
+	"audio/x-ms-wax":                true, // ASX playlist
+	"video/x-ms-wvx":                true, // ASX playlist
+	"application/vnd.apple.mpegurl": true, // HLS playlist
+	"application/x-mpegurl":         true, // HLS playlist
+	"application/xspf+xml":          true, // XSPF playlist
+	"application/vnd.ms-wpl":        true, // WPL playlist
+	"application/vnd.ms-zpl":        true, // ZPL playlist
 }
 
 // PlaylistResp the playlist response.
@@ -63,85 +51,22 @@ type PlaylistResp struct {
 	// ContentTypeDetected holds return value of http.DetectContentType().
 	ContentTypeDetected string
 	// Raw is the raw response. If the response was detected as binary it
-	// has only first playlistReadLimit bytes.
+	// has only first playlistReadLimit bytes. Playlist.Parse transcodes
+	// it to UTF-8.
 	Raw []byte
-	// Origin is where the playlist came from: ORIGIN_FILE, ORIGIN_URL
+	// Origin is where the playlist came from: ORIGIN_FILE, ORIGIN_URL, ORIGIN_READER
 	Origin string
 }
 
-// NewPlaylistRespUrl creates new playlist response. Takes URL to potential playlist.
+// NewPlaylistRespUrl creates new playlist response. Takes URL to potential playlist
+// and timeout in seconds. Use Fetcher for more control over the request.
+// Returns *HTTPStatusError for responses with not successful status code.
 func NewPlaylistRespUrl(url string, timeout int) (*PlaylistResp, error) {
 
-	plr := new(PlaylistResp)
-	plr.Url = url
-	plr.Origin = ORIGIN_URL
-	client := &http.Client{}
-
-	req, err := http.NewRequest("GET", url, nil)
-	if err != nil {
-		return plr, err
-	}
-
-	var response *HttpResp
-	var resp *http.Response
-
-	// Channel to get response on
-	retch := make(chan *HttpResp)
-
-	go getBody(client, req, retch)
-
-	// Wait for response or give up after timeout
-	select {
-
-	case response = <-retch:
-		resp = response.resp
-		err = response.err
-
-	case <-time.After(time.Duration(timeout) * time.Second):
-		resp = nil
-		err = errors.New("Timeout connecting to URL.")
-	}
-
-	if resp == nil {
-		// This means that ShoutCast server responded with its header
-		// which is not recognized by http package.
-		// The header is usually in the form of ICY 200 OK
-		// In this case we set the response to be 200 but containing binary data.
-		if strings.Contains(err.Error(), "malformed HTTP version \"ICY\"") {
-			plr.StatusCode = 200
-			plr.ContentType = "application/octet-stream"
-			plr.ContentTypeDetected = "application/octet-stream"
-			err = nil
-		}
+	ctx, cancel := context.WithTimeout(context.Background(), time.Duration(timeout)*time.Second)
+	defer cancel()
 
-		return plr, err
-	}
-
-	if err != nil {
-		return plr, err
-	}
-
-	defer resp.Body.Close()
-
-	plr.StatusCode = resp.StatusCode
-	plr.ContentType = resp.Header.Get("Content-Type")
-
-	// If it's text response we read whole content
-	if _, ok := TEXT[plr.ContentType]; ok {
-		plr.Raw, err = ioutil.ReadAll(resp.Body)
-	} else {
-		// Here we read only playlistReadLimit bytes so we can use DetectContentType
-		plr.Raw, err = ioutil.ReadAll(io.LimitReader(resp.Body, playlistReadLimit))
-
-	}
-
-	if err != nil {
-		return plr, err
-	}
-
-	plr.ContentTypeDetected = http.DetectContentType(plr.Raw)
-
-	return plr, err
+	return NewFetcher(nil).Fetch(ctx, url)
 }
 
 // NewPlaylistRespFile creates new playlist response from local file.
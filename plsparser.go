@@ -5,7 +5,7 @@
 //
 // Licensed under the MIT license
 
-// Package plparser provides primitives to parse PLS, ASX, ASF and M3U playlists.
+// Package plparser provides primitives to parse PLS, ASX, ASF, M3U, HLS, XSPF, WPL and ZPL playlists.
 package plparser
 
 import (
@@ -13,88 +13,324 @@ import (
 	"bytes"
 	"io"
 	"regexp"
+	"sort"
 	"strconv"
+	"strings"
+	"time"
 )
 
-// plsRegs regular expressions to match interesting parts of PLS playlist.
-var plsRegs = []struct {
-	name string
-	reg  *regexp.Regexp
-}{
-	{"Title", regexp.MustCompile(`(?is)title([0-9]+)(?:\s+)?=(?:\s+)?(.*)`)},
-	{"Url", regexp.MustCompile(`(?i)file([0-9]+)(?:\s+)?=(?:\s+)?(.*)`)}}
+func init() {
+	RegisterFormat(&Format{
+		Name:       "pls",
+		MimeTypes:  []string{"audio/x-scpls", "audio/scpls"},
+		Extensions: []string{".pls"},
+		Detect:     detectPls,
+		New:        func(raw []byte) Playlister { return NewPlsParser(raw) },
+	})
+}
+
+// plsKeyReg regular expression to split PLS key to the name and the
+// entry index, for example File1, title 2 or Length10.
+var plsKeyReg = regexp.MustCompile(`^([A-Za-z][A-Za-z_-]*?)\s*([0-9]+)$`)
 
 // PlsParser implements PLS playlist parser.
 type PlsParser struct {
-	raw     []byte
-	reader  *bufio.Reader
-	Streams []*Stream
+	Diagnostics
+	UrlResolver
+	raw             []byte
+	reader          *bufio.Reader
+	NumberOfEntries int // -1 if missing
+	Version         int // -1 if missing
+	Streams         []*Stream
+	PreserveOrder   bool              // Keep streams in file order instead of FileN order
+	GroupMirrors    bool              // Group entries which differ only by server to one stream
+	Extras          map[string]string // Keys with no entry index other than NumberOfEntries and Version
+}
+
+// plsState holds entries collected while parsing PLS playlist. Values
+// of an entry may appear before or after its FileN key.
+type plsState struct {
+	titles      map[int]string
+	lengths     map[int]time.Duration
+	attrs       map[int]map[string]string
+	streams     map[int]*Stream
+	rejected    map[int]bool
+	order       []int // Indexes in file order
+	entriesLine int
 }
 
 // NewPlsParser returns new PLS playlist parser. Takes playlist raw content to parse.
 func NewPlsParser(raw []byte) *PlsParser {
 	pls := new(PlsParser)
 	pls.raw = raw
+	pls.NumberOfEntries = -1
+	pls.Version = -1
 	pls.Streams = make([]*Stream, 0, 10)
+	pls.Extras = make(map[string]string)
 
 	br := bytes.NewReader(pls.raw)
 	pls.reader = bufio.NewReader(br)
 	return pls
 }
 
-// Parse parses a PLS playlist.
-func (p *PlsParser) Parse() {
+// Parse parses a PLS playlist. Keys are case insensitive and white space
+// around keys, values and between key name and index is ignored.
+func (p *PlsParser) Parse() error {
+
+	st := &plsState{
+		titles:   make(map[int]string, 10),
+		lengths:  make(map[int]time.Duration, 10),
+		attrs:    make(map[int]map[string]string),
+		streams:  make(map[int]*Stream, 10),
+		rejected: make(map[int]bool, 10),
+		order:    make([]int, 0, 10),
+	}
 
-	var titles = make(map[int]string, 10)
-	var streams = make(map[int]*Stream, 10)
+	var lineNo int
 
 	for {
 		line, err := p.reader.ReadString('\n')
+		lineNo++
 
 		if err != nil && err != io.EOF {
+			p.AddDiagnostic(lineNo, 0, SeverityError, RULE_READ_ERROR, "Read error: "+err.Error(), "")
+			return &ParseError{Line: lineNo, Err: err}
+		}
+
+		text := fixString(line)
+		if lineNo == 1 {
+			text = strings.TrimPrefix(text, "\ufeff")
+		}
+
+		p.parseLine(lineNo, text, st)
+
+		if err == io.EOF {
 			break
 		}
+	}
+
+	if !p.PreserveOrder {
+		sort.Ints(st.order)
+	}
+
+	for _, idx := range st.order {
+		if !st.rejected[idx] {
+			p.Streams = append(p.Streams, st.streams[idx])
+		}
+	}
+
+	if p.GroupMirrors {
+		p.Streams = groupMirrors(p.Streams)
+	}
+
+	p.validate(st.streams, st.entriesLine)
+
+	return nil
+}
+
+// parseLine parses one PLS line in form of key=value.
+func (p *PlsParser) parseLine(lineNo int, text string, st *plsState) {
+
+	if isPlsIgnored(text) {
+		return
+	}
+
+	pos := strings.Index(text, "=")
+	if pos == -1 {
+		p.AddDiagnostic(lineNo, 1, SeverityWarning, RULE_MISSING_EQUALS, "Missing '=' in line", text)
+		return
+	}
+
+	key := strings.TrimSpace(text[:pos])
+	value := strings.TrimSpace(text[pos+1:])
+
+	name, idx := plsKey(key)
+
+	if idx == -1 {
+		switch strings.ToLower(name) {
+		case "numberofentries":
+			p.NumberOfEntries, _ = strconv.Atoi(value)
+			st.entriesLine = lineNo
+		case "version":
+			p.Version, _ = strconv.Atoi(value)
+		default:
+			p.Extras[key] = value
+		}
+		return
+	}
+
+	stream := st.streams[idx]
+
+	switch strings.ToLower(name) {
+
+	case "file":
+		if stream != nil {
+			p.AddDiagnostic(lineNo, 1, SeverityWarning, RULE_DUPLICATE_INDEX, "Duplicate "+key+" entry", text)
+		} else {
+			st.order = append(st.order, idx)
+		}
 
-		for _, s := range plsRegs {
-			values := s.reg.FindStringSubmatch(line)
+		stream = NewStream(idx)
+		stream.OriginalUrl = value
+		stream.Url = p.resolveUrl(value)
+		stream.Title = st.titles[idx]
+		stream.Duration = st.lengths[idx]
 
-			if len(values) == 3 {
+		for k, v := range st.attrs[idx] {
+			setPlsAttr(stream, k, v)
+		}
 
-				v := fixString(values[2])
-				idx64, _ := strconv.ParseInt(values[1], 10, 0)
-				idx := int(idx64)
+		// Rejected streams are kept until the end of parsing
+		// so their titles and indexes are still tracked
+		st.rejected[idx] = !p.checkStream(&p.Diagnostics, stream, lineNo, 1, text)
+		st.streams[idx] = stream
 
-				if s.name == "Url" {
+	case "title":
+		if _, ok := st.titles[idx]; ok {
+			p.AddDiagnostic(lineNo, 1, SeverityWarning, RULE_DUPLICATE_INDEX, "Duplicate "+key+" entry", text)
+		}
 
-					stream := NewStream(idx)
-					stream.Url = v
+		st.titles[idx] = value
+		if stream != nil {
+			stream.Title = value
+		}
 
-					if title, ok := titles[idx]; ok {
-						stream.Title = title
-					}
+	case "length":
+		if _, ok := st.lengths[idx]; ok {
+			p.AddDiagnostic(lineNo, 1, SeverityWarning, RULE_DUPLICATE_INDEX, "Duplicate "+key+" entry", text)
+		}
 
-					streams[idx] = stream
-				} else {
-					if stream, ok := streams[idx]; ok {
-						stream.Title = v
-					} else {
-						titles[idx] = v
-					}
-				}
-			}
+		st.lengths[idx] = parsePlsLength(value)
+		if stream != nil {
+			stream.Duration = st.lengths[idx]
 		}
 
-		if err == io.EOF {
-			break
+	default:
+		if st.attrs[idx] == nil {
+			st.attrs[idx] = make(map[string]string, 2)
+		}
+
+		st.attrs[idx][name] = value
+		if stream != nil {
+			setPlsAttr(stream, name, value)
 		}
 	}
+}
+
+// validate adds validation findings for parsed playlist.
+func (p *PlsParser) validate(streams map[int]*Stream, entriesLine int) {
+
+	switch {
+	case p.NumberOfEntries == -1:
+		p.AddFinding(0, 0, SeverityWarning, RULE_PLS_MISSING_ENTRIES, "Missing NumberOfEntries", "")
+	case p.NumberOfEntries != len(streams):
+		p.AddFinding(entriesLine, 1, SeverityWarning, RULE_PLS_ENTRIES_MISMATCH,
+			"NumberOfEntries is "+strconv.Itoa(p.NumberOfEntries)+" but found "+strconv.Itoa(len(streams))+" entries", "")
+	}
 
-	for _, v := range streams {
-		p.Streams = append(p.Streams, v)
+	if p.Version == -1 {
+		p.AddFinding(0, 0, SeverityWarning, RULE_PLS_MISSING_VERSION, "Missing Version", "")
 	}
+
+	indexes := make(map[int]bool, len(streams))
+	for idx := range streams {
+		indexes[idx] = true
+	}
+
+	checkIndexGaps(&p.Diagnostics, "File", indexes)
 }
 
-// GetStreams gets list of found streams in the playlist.
+// GetStreams gets list of found streams in the playlist ordered by FileN
+// index or in file order if PreserveOrder is set.
 func (p *PlsParser) GetStreams() []*Stream {
 	return p.Streams
 }
+
+// GetInfo gets playlist level metadata.
+func (p *PlsParser) GetInfo() *PlaylistInfo {
+
+	info := new(PlaylistInfo)
+
+	if p.Version != -1 {
+		info.Version = strconv.Itoa(p.Version)
+	}
+
+	if p.NumberOfEntries != -1 {
+		info.Entries = p.NumberOfEntries
+	}
+
+	if len(p.Extras) > 0 {
+		info.Attributes = make(map[string]string, len(p.Extras))
+		for k, v := range p.Extras {
+			info.Attributes[k] = v
+		}
+	}
+
+	return info
+}
+
+// SetPreserveOrder sets PreserveOrder.
+func (p *PlsParser) SetPreserveOrder(preserve bool) {
+	p.PreserveOrder = preserve
+}
+
+// SetGroupMirrors sets GroupMirrors.
+func (p *PlsParser) SetGroupMirrors(group bool) {
+	p.GroupMirrors = group
+}
+
+// detectPls returns true if playlist is a PLS playlist.
+func detectPls(header string, raw []byte) bool {
+	return header == "[playlist]"
+}
+
+// plsKey splits PLS key to the name and the entry index.
+// Returns -1 index for keys with no index.
+func plsKey(key string) (string, int) {
+
+	values := plsKeyReg.FindStringSubmatch(key)
+	if len(values) != 3 {
+		return key, -1
+	}
+
+	idx, err := strconv.Atoi(values[2])
+	if err != nil {
+		return key, -1
+	}
+
+	return values[1], idx
+}
+
+// parsePlsLength parses LengthN value in seconds. Negative values mean
+// live stream, invalid values are treated as unknown length.
+func parsePlsLength(value string) time.Duration {
+
+	secs, err := strconv.ParseInt(value, 10, 64)
+	switch {
+	case err != nil:
+		return 0
+	case secs < 0:
+		return LiveDuration
+	}
+
+	return time.Duration(secs) * time.Second
+}
+
+// setPlsAttr sets not known key of PLS entry as stream attribute.
+// GenreN is also set as stream Genre.
+func setPlsAttr(s *Stream, name, value string) {
+	if s.Attributes == nil {
+		s.Attributes = make(map[string]string, 2)
+	}
+	s.Attributes[name] = value
+
+	if strings.EqualFold(name, "genre") {
+		s.Genre = value
+	}
+}
+
+// isPlsIgnored returns true for PLS lines with no key value pair:
+// empty lines, section headers and comments.
+func isPlsIgnored(line string) bool {
+	return line == "" || strings.HasPrefix(line, "[") || strings.HasPrefix(line, ";") || strings.HasPrefix(line, "#")
+}
@@ -8,93 +8,97 @@
 package plparser
 
 import (
-	"regexp"
 	"testing"
+	"time"
 )
 
-func TestPlsRegExp(t *testing.T) {
+func TestPlsKeys(t *testing.T) {
 
-	var urlreg, titlereg *regexp.Regexp
-
-	var testUrlLines = []struct {
-		line     string
-		expected string
-		idx      string
-		matches  int
-	}{
-		{"File1=http://live.example.com:8888", "http://live.example.com:8888", "1", 3},
-		{"file2=http://live.example.com:8888", "http://live.example.com:8888", "2", 3},
-		{"file1 = http://live.example.com:8888", "http://live.example.com:8888", "1", 3},
-		{"FILE2 =http://live.example.com:8888", "http://live.example.com:8888", "2", 3},
-		{"FiLe2= http://live.example.com:8888", "http://live.example.com:8888", "2", 3},
-		{"", "", "0", 0},
-		{"not valid line", "", "0", 0},
-	}
-
-	var testTitleLines = []struct {
-		line     string
-		expected string
-		idx      string
-		matches  int
+	var tests = []struct {
+		key  string
+		name string
+		idx  int
 	}{
-		{"Title1=Some title text", "Some title text", "1", 3},
-		{"title1=Some title text", "Some title text", "1", 3},
-		{"TitLe1=Some title text", "Some title text", "1", 3},
-		{"TitLe1 = Some title text", "Some title text", "1", 3},
-		{"TitLe1 =Some title text", "Some title text", "1", 3},
-		{"TITLE1= Some title text", "Some title text", "1", 3},
-		{"", "", "0", 0},
-		{"not valid line", "", "0", 0},
-	}
-
-	for _, reg := range plsRegs {
-		switch reg.name {
-
-		case "Title":
-			titlereg = reg.reg
-		case "Url":
-			urlreg = reg.reg
-		}
+		{"File1", "File", 1},
+		{"file2", "file", 2},
+		{"FILE 2", "FILE", 2},
+		{"File10", "File", 10},
+		{"TitLe1", "TitLe", 1},
+		{"Length3", "Length", 3},
+		{"NumberOfEntries", "NumberOfEntries", -1},
+		{"X-Genre", "X-Genre", -1},
+		{"", "", -1},
+		{"12", "12", -1},
 	}
 
-	for _, testLine := range testUrlLines {
-		values := urlreg.FindStringSubmatch(testLine.line)
-
-		if len(values) != testLine.matches {
-			t.Fatalf("Expected to find %d matches for %s", testLine.matches, testLine.line)
+	for _, test := range tests {
+		if name, idx := plsKey(test.key); name != test.name || idx != test.idx {
+			t.Fatalf("Expected key %s %d got %s %d (%s)", test.name, test.idx, name, idx, test.key)
 		}
+	}
+}
 
-		if testLine.matches > 0 {
-
-			if values[2] != testLine.expected {
-				t.Fatalf("Expected url '%s' == '%s'", testLine.expected, values[2])
-			}
+func TestPlsLines(t *testing.T) {
+
+	raw := "\ufeff[playlist]\n" +
+		"; comment = not a key\n" +
+		"# comment\n" +
+		"  file1 = http://live.example.com:8881/?a=b\n" +
+		"TITLE1= Some = title\n" +
+		"Length1=-1\n" +
+		"Title2=Track\r\n" +
+		"length 2 = 215\r\n" +
+		"Genre2=Jazz\n" +
+		"File2=http://ex.com/track.mp3\n" +
+		"Length3=abc\n" +
+		"File3=http://ex.com/other.mp3\n" +
+		"X-Station=Example\n" +
+		"numberofentries = 3\n" +
+		"VERSION=2\n"
+
+	parser := NewPlsParser([]byte(raw))
+	parser.Parse()
 
-			if values[1] != testLine.idx {
-				t.Fatalf("Expected idx '%s' == '%s'", testLine.expected, values[1])
-			}
-		}
+	if len(parser.Streams) != 3 {
+		t.Fatalf("Expected 3 streams got %d", len(parser.Streams))
 	}
 
-	for _, testLine := range testTitleLines {
-		values := titlereg.FindStringSubmatch(testLine.line)
+	var expected = []struct {
+		url      string
+		title    string
+		duration time.Duration
+	}{
+		{"http://live.example.com:8881/?a=b", "Some = title", LiveDuration},
+		{"http://ex.com/track.mp3", "Track", 215 * time.Second},
+		{"http://ex.com/other.mp3", "", 0},
+	}
 
-		if len(values) != testLine.matches {
-			t.Fatalf("Expected to find %d matches for %s", testLine.matches, testLine.line)
+	for i, e := range expected {
+		s := parser.Streams[i]
+		if s.Url != e.url || s.Title != e.title || s.Duration != e.duration {
+			t.Fatalf("Expected stream %s '%s' %s got %s '%s' %s", e.url, e.title, e.duration, s.Url, s.Title, s.Duration)
 		}
+	}
 
-		if testLine.matches > 0 {
+	if parser.Streams[1].Attributes["Genre"] != "Jazz" || parser.Streams[1].Genre != "Jazz" {
+		t.Fatalf("Expected Genre2 as stream genre and attribute got %s %v", parser.Streams[1].Genre, parser.Streams[1].Attributes)
+	}
 
-			if values[2] != testLine.expected {
-				t.Fatalf("Expected title %s == %s", testLine.expected, values[2])
-			}
+	if parser.NumberOfEntries != 3 || parser.Version != 2 {
+		t.Fatalf("Expected 3 entries and version 2 got %d %d", parser.NumberOfEntries, parser.Version)
+	}
 
-			if values[1] != testLine.idx {
-				t.Fatalf("Expected idx %s == %s", testLine.expected, values[1])
-			}
-		}
+	if len(parser.Extras) != 1 || parser.Extras["X-Station"] != "Example" {
+		t.Fatalf("Expected extras X-Station got %v", parser.Extras)
 	}
 
+	if info := parser.GetInfo(); info.Attributes["X-Station"] != "Example" {
+		t.Fatalf("Expected extras in playlist info got %+v", info)
+	}
+
+	if diags := parser.Validate(); len(diags) != 0 {
+		t.Fatalf("Expected no diagnostics got %v", diags)
+	}
 }
 
 func TestPlsFiles(t *testing.T) {
@@ -194,6 +198,36 @@ func testPLSFile(filePath string, rulez map[int]plTestStruct, t *testing.T) {
 	}
 }
 
+func TestPlsOrder(t *testing.T) {
+
+	raw := []byte("[playlist]\nFile3=http://ex.com/3\nFile1=http://ex.com/1\nFile10=http://ex.com/10\nFile2=http://ex.com/2\n")
+
+	var tests = []struct {
+		preserve bool
+		expected []int
+	}{
+		{false, []int{1, 2, 3, 10}},
+		{true, []int{3, 1, 10, 2}},
+	}
+
+	for _, test := range tests {
+
+		parser := NewPlsParser(raw)
+		parser.PreserveOrder = test.preserve
+		parser.Parse()
+
+		if len(parser.Streams) != len(test.expected) {
+			t.Fatalf("Expected %d streams got %d", len(test.expected), len(parser.Streams))
+		}
+
+		for i, idx := range test.expected {
+			if parser.Streams[i].Index != idx {
+				t.Fatalf("Expected stream %d to have index %d got %d (preserve: %v)", i, idx, parser.Streams[i].Index, test.preserve)
+			}
+		}
+	}
+}
+
 func BenchmarkPlsParsing(b *testing.B) {
 
 	testFile := getPLFile("./testpls/pls1.pls")
@@ -0,0 +1,258 @@
+// Part of the Go playlist parser package
+//
+// Copyright 2013 Rafal Zajac rzajac<at>gmail<dot>com. All rights reserved.
+// http://github.com/rzajac/plparser
+//
+// Licensed under the MIT license
+
+package plparser
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Playlist formats the playlist writers support.
+const (
+	FORMAT_PLS  = "pls"
+	FORMAT_M3U  = "m3u"
+	FORMAT_ASX  = "asx"
+	FORMAT_XSPF = "xspf"
+)
+
+// Write writes playlist streams to w in the given format.
+func (p *Playlist) Write(w io.Writer, format string) error {
+	return WritePlaylist(w, format, p.Streams)
+}
+
+// WritePlaylist writes streams to w in the given format.
+func WritePlaylist(w io.Writer, format string, streams []*Stream) error {
+
+	switch format {
+	case FORMAT_PLS:
+		return WritePls(w, streams)
+	case FORMAT_M3U:
+		return WriteM3u(w, streams)
+	case FORMAT_ASX:
+		return WriteAsx(w, streams)
+	case FORMAT_XSPF:
+		return WriteXspf(w, streams)
+	}
+
+	return newError(ErrUnsupportedFormat, format, nil)
+}
+
+// WritePls writes streams to w as PLS version 2 playlist. PLS has one
+// URL per entry so stream Fallbacks are written as entries following
+// the stream with the same title. They can be grouped back with
+// MirrorGrouper.
+func WritePls(w io.Writer, streams []*Stream) error {
+
+	bw := bufio.NewWriter(w)
+
+	fmt.Fprintln(bw, "[playlist]")
+
+	var idx int
+
+	for _, s := range streams {
+		for _, u := range append([]string{s.Url}, s.Fallbacks...) {
+			idx++
+
+			fmt.Fprintf(bw, "File%d=%s\n", idx, u)
+
+			if s.Title != "" {
+				fmt.Fprintf(bw, "Title%d=%s\n", idx, fixString(s.Title))
+			}
+
+			fmt.Fprintf(bw, "Length%d=%d\n", idx, durationSeconds(s.Duration))
+		}
+	}
+
+	fmt.Fprintf(bw, "NumberOfEntries=%d\n", idx)
+	fmt.Fprintln(bw, "Version=2")
+
+	return bw.Flush()
+}
+
+// WriteM3u writes streams to w as extended M3U playlist.
+func WriteM3u(w io.Writer, streams []*Stream) error {
+
+	bw := bufio.NewWriter(w)
+
+	fmt.Fprintln(bw, "#EXTM3U")
+
+	for _, s := range streams {
+		fmt.Fprintf(bw, "#EXTINF:%d", durationSeconds(s.Duration))
+
+		// Write attributes in the same order every time. Values can't be
+		// escaped in EXTINF so double quotes are replaced with single ones.
+		for _, k := range sortedKeys(s.Attributes) {
+			fmt.Fprintf(bw, " %s=\"%s\"", k, strings.Replace(fixString(s.Attributes[k]), "\"", "'", -1))
+		}
+
+		fmt.Fprintf(bw, ",%s\n", fixString(s.Title))
+		fmt.Fprintln(bw, s.Url)
+	}
+
+	return bw.Flush()
+}
+
+// WriteAsx writes streams to w as ASX 3.0 playlist.
+func WriteAsx(w io.Writer, streams []*Stream) error {
+
+	bw := bufio.NewWriter(w)
+
+	fmt.Fprintln(bw, `<asx version="3.0">`)
+
+	for _, s := range streams {
+		fmt.Fprintln(bw, "\t<entry>")
+		writeXmlElement(bw, "\t\t", "title", s.Title)
+		writeXmlElement(bw, "\t\t", "author", s.Author)
+		writeXmlElement(bw, "\t\t", "copyright", s.Copyright)
+		writeXmlElement(bw, "\t\t", "abstract", s.Description)
+		writeXmlHref(bw, "\t\t", "logo", s.Logo)
+		writeXmlHref(bw, "\t\t", "moreinfo", s.MoreInfo)
+		writeXmlHref(bw, "\t\t", "ref", s.Url)
+
+		// Fallbacks are other REF elements of the entry
+		for _, u := range s.Fallbacks {
+			writeXmlHref(bw, "\t\t", "ref", u)
+		}
+
+		params := asxParams(s)
+		for _, name := range sortedKeys(params) {
+			fmt.Fprintf(bw, "\t\t<param name=\"%s\" value=\"%s\"/>\n", xmlEscape(name), xmlEscape(params[name]))
+		}
+
+		fmt.Fprintln(bw, "\t</entry>")
+	}
+
+	fmt.Fprintln(bw, "</asx>")
+
+	return bw.Flush()
+}
+
+// WriteXspf writes streams to w as XSPF version 1 playlist.
+func WriteXspf(w io.Writer, streams []*Stream) error {
+
+	bw := bufio.NewWriter(w)
+
+	fmt.Fprintln(bw, `<?xml version="1.0" encoding="UTF-8"?>`)
+	fmt.Fprintf(bw, "<playlist version=\"1\" xmlns=\"%s\">\n", xspfNamespace)
+	fmt.Fprintln(bw, "\t<trackList>")
+
+	for _, s := range streams {
+		fmt.Fprintln(bw, "\t\t<track>")
+		writeXmlElement(bw, "\t\t\t", "location", s.Url)
+
+		// Fallbacks are other location elements of the track
+		for _, u := range s.Fallbacks {
+			writeXmlElement(bw, "\t\t\t", "location", u)
+		}
+
+		writeXmlElement(bw, "\t\t\t", "title", s.Title)
+		writeXmlElement(bw, "\t\t\t", "creator", s.Author)
+		writeXmlElement(bw, "\t\t\t", "annotation", s.Description)
+		writeXmlElement(bw, "\t\t\t", "info", s.MoreInfo)
+		writeXmlElement(bw, "\t\t\t", "image", s.Logo)
+		writeXmlElement(bw, "\t\t\t", "album", s.Album)
+
+		if s.Duration > 0 {
+			writeXmlElement(bw, "\t\t\t", "duration", strconv.FormatInt(int64(s.Duration/time.Millisecond), 10))
+		}
+
+		fmt.Fprintln(bw, "\t\t</track>")
+	}
+
+	fmt.Fprintln(bw, "\t</trackList>")
+	fmt.Fprintln(bw, "</playlist>")
+
+	return bw.Flush()
+}
+
+// durationSeconds returns stream duration in seconds or -1 if duration is unknown.
+func durationSeconds(d time.Duration) int64 {
+	if d <= 0 {
+		return -1
+	}
+
+	return int64(d / time.Second)
+}
+
+// writeXmlElement writes XML element with escaped text. Empty values are skipped.
+func writeXmlElement(w io.Writer, indent, name, value string) {
+	if value == "" {
+		return
+	}
+
+	fmt.Fprintf(w, "%s<%s>%s</%s>\n", indent, name, xmlEscape(value), name)
+}
+
+// writeXmlHref writes empty XML element with href attribute. Empty values are skipped.
+func writeXmlHref(w io.Writer, indent, name, value string) {
+	if value == "" {
+		return
+	}
+
+	fmt.Fprintf(w, "%s<%s href=\"%s\"/>\n", indent, name, xmlEscape(value))
+}
+
+// asxParams returns stream attributes written as ASX PARAM elements.
+// Well known stream fields not present in attributes are added.
+func asxParams(s *Stream) map[string]string {
+
+	params := make(map[string]string, len(s.Attributes)+4)
+	for k, v := range s.Attributes {
+		params[k] = v
+	}
+
+	var known = []struct{ name, value string }{
+		{"Genre", s.Genre},
+		{"Location", s.Location},
+		{"Homepage", s.Homepage},
+		{"HTMLView", s.HTMLView},
+	}
+
+	for _, k := range known {
+		if k.value == "" || hasKeyFold(params, k.name) {
+			continue
+		}
+		params[k.name] = k.value
+	}
+
+	return params
+}
+
+// hasKeyFold returns true if m has a key equal to name under case folding.
+func hasKeyFold(m map[string]string, name string) bool {
+	for k := range m {
+		if strings.EqualFold(k, name) {
+			return true
+		}
+	}
+	return false
+}
+
+// sortedKeys returns map keys in sorted order.
+func sortedKeys(m map[string]string) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// xmlEscape escapes text so it can be safely used in XML text and attributes.
+func xmlEscape(s string) string {
+	var b bytes.Buffer
+	xml.EscapeText(&b, []byte(s))
+	return b.String()
+}
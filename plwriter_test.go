@@ -0,0 +1,256 @@
+// Part of the Go playlist parser package
+//
+// Copyright 2013 Rafal Zajac rzajac<at>gmail<dot>com. All rights reserved.
+// http://github.com/rzajac/plparser
+//
+// Licensed under the MIT license
+
+package plparser
+
+import (
+	"bytes"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+func getWriterTestStreams() []*Stream {
+
+	s1 := NewStream(1)
+	s1.Title = "Title one"
+	s1.Author = "Author"
+	s1.Copyright = "Copyright"
+	s1.Description = "Description"
+	s1.Logo = "http://logo.ex.com/l.gif"
+	s1.MoreInfo = "http://mi.ex.com/"
+	s1.Url = "http://live1.example.com:8881/"
+	s1.Duration = LiveDuration
+	s1.Attributes = map[string]string{"tvg-id": "one", "group-title": "News"}
+
+	s2 := NewStream(2)
+	s2.Url = "http://live2.example.com:8882/track.mp3"
+	s2.Duration = 215 * time.Second
+
+	return []*Stream{s1, s2}
+}
+
+func TestWritePlaylist(t *testing.T) {
+
+	var tests = []struct {
+		format string
+		pltype string
+	}{
+		{FORMAT_PLS, "pls"},
+		{FORMAT_M3U, "m3u"},
+		{FORMAT_ASX, "asx"},
+		{FORMAT_XSPF, "xspf"},
+	}
+
+	streams := getWriterTestStreams()
+
+	// Not all parsers keep streams order so we find them by URL
+	expected := make(map[string]*Stream, len(streams))
+	for _, s := range streams {
+		expected[s.Url] = s
+	}
+
+	for _, test := range tests {
+
+		var buf bytes.Buffer
+
+		if err := WritePlaylist(&buf, test.format, streams); err != nil {
+			t.Fatalf("Unexpected error writing %s: %s", test.format, err)
+		}
+
+		plr := new(PlaylistResp)
+		plr.Raw = buf.Bytes()
+
+		pl := NewPlaylist(plr)
+		pltype, _ := pl.Parse()
+
+		if pltype != test.pltype {
+			t.Fatalf("Expected written %s to be detected as '%s' got '%s'", test.format, test.pltype, pltype)
+		}
+
+		if len(pl.Streams) != len(streams) {
+			t.Fatalf("Expected %d streams got %d (%s)", len(streams), len(pl.Streams), test.format)
+		}
+
+		for _, s := range pl.Streams {
+
+			exp, ok := expected[s.Url]
+			if !ok {
+				t.Fatalf("Stream with Url '%s' was not expected (%s)", s.Url, test.format)
+			}
+
+			if s.Title != exp.Title {
+				t.Fatalf("Expected stream (%s:%s) Title '%s' == '%s'", test.format, s.Url, exp.Title, s.Title)
+			}
+		}
+	}
+}
+
+func TestWriteM3uExtinf(t *testing.T) {
+
+	var buf bytes.Buffer
+	WriteM3u(&buf, getWriterTestStreams())
+
+	expected := "#EXTM3U\n" +
+		"#EXTINF:-1 group-title=\"News\" tvg-id=\"one\",Title one\n" +
+		"http://live1.example.com:8881/\n" +
+		"#EXTINF:215,\n" +
+		"http://live2.example.com:8882/track.mp3\n"
+
+	if buf.String() != expected {
+		t.Fatalf("Expected M3U:\n%s\ngot:\n%s", expected, buf.String())
+	}
+}
+
+func TestWriteM3uAttributes(t *testing.T) {
+
+	s := NewStream(1)
+	s.Url = "http://live1.example.com:8881/"
+	s.Title = "Title"
+	s.Attributes = map[string]string{"tvg-name": `Café "Live" \ Radio`}
+
+	var buf bytes.Buffer
+	WriteM3u(&buf, []*Stream{s})
+
+	if !strings.Contains(buf.String(), `tvg-name="Café 'Live' \ Radio",Title`) {
+		t.Fatalf("Expected attribute written without escapes got:\n%s", buf.String())
+	}
+
+	m3u := NewM3uParser(buf.Bytes())
+	m3u.Parse()
+
+	if len(m3u.Streams) != 1 {
+		t.Fatalf("Expected 1 stream got %d", len(m3u.Streams))
+	}
+
+	if v := m3u.Streams[0].Attributes["tvg-name"]; v != `Café 'Live' \ Radio` {
+		t.Fatalf("Expected tvg-name to round trip got '%s'", v)
+	}
+}
+
+func TestWritePls(t *testing.T) {
+
+	var buf bytes.Buffer
+	WritePls(&buf, getWriterTestStreams())
+
+	for _, line := range []string{"NumberOfEntries=2\n", "Version=2\n", "Length1=-1\n", "Length2=215\n"} {
+		if !strings.Contains(buf.String(), line) {
+			t.Fatalf("Expected PLS to contain '%s':\n%s", line, buf.String())
+		}
+	}
+}
+
+func TestWriteAsxParams(t *testing.T) {
+
+	s := NewStream(1)
+	s.Url = "http://live.example.com/"
+	s.Genre = "Jazz"
+	s.Attributes = map[string]string{"genre": "Blues", "Station": "A&B"}
+
+	var buf bytes.Buffer
+	WriteAsx(&buf, []*Stream{s})
+
+	expected := "\t\t<param name=\"Station\" value=\"A&amp;B\"/>\n" +
+		"\t\t<param name=\"genre\" value=\"Blues\"/>\n"
+
+	if !strings.Contains(buf.String(), expected) || strings.Contains(buf.String(), "Jazz") {
+		t.Fatalf("Expected ASX params:\n%s\ngot:\n%s", expected, buf.String())
+	}
+
+	parser := NewAsxParser(buf.Bytes())
+	parser.Parse()
+
+	if len(parser.Streams) != 1 || parser.Streams[0].Genre != "Blues" || parser.Streams[0].Attributes["Station"] != "A&B" {
+		t.Fatalf("Expected params to survive round trip got %+v", parser.Streams)
+	}
+}
+
+func TestWriteAsxFallbacks(t *testing.T) {
+
+	s := NewStream(1)
+	s.Url = "mms://live.example.com/"
+	s.Fallbacks = []string{"http://live.example.com/"}
+
+	var buf bytes.Buffer
+	WriteAsx(&buf, []*Stream{s})
+
+	parser := NewAsxParser(buf.Bytes())
+	parser.Parse()
+
+	if len(parser.Streams) != 1 || !reflect.DeepEqual(parser.Streams[0].Fallbacks, s.Fallbacks) {
+		t.Fatalf("Expected fallbacks to survive round trip got:\n%s", buf.String())
+	}
+}
+
+func TestWriteXspfFallbacks(t *testing.T) {
+
+	s := NewStream(1)
+	s.Url = "http://ice1.example.com/live"
+	s.Fallbacks = []string{"http://ice2.example.com/live", "http://ice3.example.com/live"}
+
+	var buf bytes.Buffer
+	WriteXspf(&buf, []*Stream{s})
+
+	parser := NewXspfParser(buf.Bytes())
+	parser.Parse()
+
+	if len(parser.Streams) != 1 || parser.Streams[0].Url != s.Url || !reflect.DeepEqual(parser.Streams[0].Fallbacks, s.Fallbacks) {
+		t.Fatalf("Expected fallbacks to survive round trip got:\n%s", buf.String())
+	}
+}
+
+func TestWritePlsFallbacks(t *testing.T) {
+
+	s := NewStream(1)
+	s.Url = "http://ice1.example.com/live"
+	s.Title = "Live"
+	s.Fallbacks = []string{"http://ice2.example.com/live"}
+
+	var buf bytes.Buffer
+	WritePls(&buf, []*Stream{s})
+
+	for _, line := range []string{"File2=http://ice2.example.com/live\n", "Title2=Live\n", "NumberOfEntries=2\n"} {
+		if !strings.Contains(buf.String(), line) {
+			t.Fatalf("Expected PLS to contain '%s':\n%s", line, buf.String())
+		}
+	}
+
+	parser := NewPlsParser(buf.Bytes())
+	parser.SetGroupMirrors(true)
+	parser.Parse()
+
+	if len(parser.Streams) != 1 || parser.Streams[0].Url != s.Url || !reflect.DeepEqual(parser.Streams[0].Fallbacks, s.Fallbacks) {
+		t.Fatalf("Expected fallbacks to survive round trip got:\n%s", buf.String())
+	}
+}
+
+func TestWriteXmlEscaping(t *testing.T) {
+
+	s := NewStream(1)
+	s.Title = "Rock & <Roll>"
+	s.Url = "http://live.example.com/?a=1&b=2"
+
+	for _, format := range []string{FORMAT_ASX, FORMAT_XSPF} {
+
+		var buf bytes.Buffer
+		WritePlaylist(&buf, format, []*Stream{s})
+
+		if !strings.Contains(buf.String(), "Rock &amp; &lt;Roll&gt;") || !strings.Contains(buf.String(), "?a=1&amp;b=2") {
+			t.Fatalf("Expected escaped values in %s:\n%s", format, buf.String())
+		}
+	}
+}
+
+func TestWriteUnsupported(t *testing.T) {
+
+	var buf bytes.Buffer
+
+	if err := WritePlaylist(&buf, "bad", getWriterTestStreams()); err == nil {
+		t.Fatalf("Expected error for unsupported format")
+	}
+}
@@ -0,0 +1,157 @@
+// Part of the Go playlist parser package
+//
+// Copyright 2013 Rafal Zajac rzajac<at>gmail<dot>com. All rights reserved.
+// http://github.com/rzajac/plparser
+//
+// Licensed under the MIT license
+
+package plparser
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"net/http"
+	"strconv"
+)
+
+// Default limits used by ParseReader.
+const (
+	DefaultMaxSize       = 1 << 20 // 1MB
+	DefaultMaxLineLength = 8 << 10 // 8kB
+)
+
+// ParseOptions are options for ParseReader.
+type ParseOptions struct {
+	// MaxSize is the maximum number of bytes read. Zero means DefaultMaxSize.
+	MaxSize int64
+	// MaxLineLength is the maximum length of a line in bytes.
+	// Zero means DefaultMaxLineLength.
+	MaxLineLength int
+	// Url is the location of the playlist if it's known.
+	Url string
+	// ContentType is the content type of the playlist if it's known.
+	ContentType string
+	// SchemePolicy decides which stream URL schemes are accepted.
+	// Nil means the default policy.
+	SchemePolicy *SchemePolicy
+	// PreserveOrder keeps streams of formats with indexed entries in file order.
+	PreserveOrder bool
+	// GroupMirrors groups PLS entries which differ only by server.
+	GroupMirrors bool
+	// AsxLoader loads playlists referenced by ASX ENTRYREF elements.
+	// Nil means ENTRYREF elements are not followed.
+	AsxLoader AsxLoader
+}
+
+// limitedReader reads from underlying reader enforcing ParseOptions limits
+// and context cancellation.
+type limitedReader struct {
+	ctx     context.Context
+	r       io.Reader
+	opts    *ParseOptions
+	read    int64
+	line    int // 1 based number of the line being read
+	lineLen int
+}
+
+// Read implements io.Reader interface.
+func (lr *limitedReader) Read(p []byte) (int, error) {
+
+	if err := lr.ctx.Err(); err != nil {
+		return 0, contextError(err)
+	}
+
+	n, err := lr.r.Read(p)
+
+	lr.read += int64(n)
+	if lr.read > lr.opts.MaxSize {
+		return n, newError(ErrTooLarge, "exceeds "+strconv.FormatInt(lr.opts.MaxSize, 10)+" bytes", nil)
+	}
+
+	for _, b := range p[:n] {
+		if b == '\n' {
+			lr.line++
+			lr.lineLen = 0
+			continue
+		}
+
+		lr.lineLen++
+		if lr.lineLen > lr.opts.MaxLineLength {
+			err := newError(ErrTooLarge, "line exceeds "+strconv.Itoa(lr.opts.MaxLineLength)+" bytes", nil)
+			return n, &ParseError{Line: lr.line, Column: lr.lineLen, Err: err}
+		}
+	}
+
+	return n, err
+}
+
+// ParseReader reads playlist from r, detects its type and parses it.
+// Reading stops with an error when ctx is canceled or any of the limits
+// set in opts is exceeded. The opts may be nil. Returns ErrNotPlaylist
+// for binary or HTML content and ErrUnsupportedFormat when playlist type
+// is not detected together with the not parsed playlist.
+func ParseReader(ctx context.Context, r io.Reader, opts *ParseOptions) (*Playlist, error) {
+
+	o := ParseOptions{}
+	if opts != nil {
+		o = *opts
+	}
+
+	if o.MaxSize <= 0 {
+		o.MaxSize = DefaultMaxSize
+	}
+
+	if o.MaxLineLength <= 0 {
+		o.MaxLineLength = DefaultMaxLineLength
+	}
+
+	lr := &limitedReader{ctx: ctx, r: r, opts: &o, line: 1}
+
+	var buf bytes.Buffer
+	if _, err := buf.ReadFrom(lr); err != nil {
+		return nil, err
+	}
+
+	plr := new(PlaylistResp)
+	plr.Url = o.Url
+	plr.Origin = ORIGIN_READER
+	plr.StatusCode = 200
+	plr.ContentType = o.ContentType
+	plr.Raw = buf.Bytes()
+	plr.ContentTypeDetected = http.DetectContentType(plr.Raw)
+
+	pl := NewPlaylist(plr)
+
+	if !plr.IsPotentialPlaylist() {
+		return pl, ErrNotPlaylist
+	}
+
+	pl.SchemePolicy = o.SchemePolicy
+	pl.PreserveOrder = o.PreserveOrder
+	pl.GroupMirrors = o.GroupMirrors
+	pl.AsxLoader = o.AsxLoader
+	if _, err := pl.Parse(); err != nil {
+		return pl, err
+	}
+
+	if err := contextError(ctx.Err()); err != nil {
+		return pl, err
+	}
+
+	if !pl.IsDetected() {
+		return pl, ErrUnsupportedFormat
+	}
+
+	return pl, nil
+}
+
+// contextError returns ErrTimeout wrapping err if the context deadline
+// was exceeded or err otherwise.
+func contextError(err error) error {
+	if err == context.DeadlineExceeded {
+		return newError(ErrTimeout, "", err)
+	}
+
+	return err
+}
@@ -0,0 +1,108 @@
+// Part of the Go playlist parser package
+//
+// Copyright 2013 Rafal Zajac rzajac<at>gmail<dot>com. All rights reserved.
+// http://github.com/rzajac/plparser
+//
+// Licensed under the MIT license
+
+package plparser
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"strings"
+	"testing"
+)
+
+func TestParseReader(t *testing.T) {
+
+	var files = map[string]struct {
+		pltype  string
+		streams int
+	}{
+		"./testpls/asf1.asf":   {"asf", 2},
+		"./testpls/asx1.asx":   {"asx", 2},
+		"./testpls/m3u2.m3u":   {"m3u", 3},
+		"./testpls/pls1.pls":   {"pls", 5},
+		"./testpls/xspf1.xspf": {"xspf", 2},
+	}
+
+	for filePath, test := range files {
+
+		pl, err := ParseReader(context.Background(), bytes.NewReader(getPLFile(filePath)), nil)
+		if err != nil {
+			t.Fatalf("Unexpected error %s (%s)", err, filePath)
+		}
+
+		if pl.Type != test.pltype {
+			t.Fatalf("Expected playlist %s to be of type '%s' but it's '%s'", filePath, test.pltype, pl.Type)
+		}
+
+		if len(pl.Streams) != test.streams {
+			t.Fatalf("Expected %d streams got %d (%s)", test.streams, len(pl.Streams), filePath)
+		}
+
+		if pl.Resp.Origin != ORIGIN_READER {
+			t.Fatalf("Expected origin '%s' got '%s'", ORIGIN_READER, pl.Resp.Origin)
+		}
+	}
+}
+
+func TestParseReaderNotDetected(t *testing.T) {
+
+	var tests = map[string]error{
+		"\n\n": ErrUnsupportedFormat,
+		string(getPLFile("./testpls/unknown1.txt")):          ErrUnsupportedFormat,
+		"<!DOCTYPE html><html><body>Not found</body></html>": ErrNotPlaylist,
+		"\x00\x01\x02\x03ID3":                                ErrNotPlaylist,
+	}
+
+	for raw, expected := range tests {
+
+		pl, err := ParseReader(context.Background(), strings.NewReader(raw), nil)
+		if !errors.Is(err, expected) {
+			t.Fatalf("Expected %v got %v for %q", expected, err, raw)
+		}
+
+		if pl == nil || pl.IsDetected() {
+			t.Fatalf("Expected not detected playlist for %q", raw)
+		}
+	}
+}
+
+func TestParseReaderLimits(t *testing.T) {
+
+	raw := getPLFile("./testpls/pls1.pls")
+
+	_, err := ParseReader(context.Background(), bytes.NewReader(raw), &ParseOptions{MaxSize: 10})
+	if !errors.Is(err, ErrTooLarge) {
+		t.Fatalf("Expected error when playlist is too large")
+	}
+
+	_, err = ParseReader(context.Background(), bytes.NewReader(raw), &ParseOptions{MaxLineLength: 20})
+	if !errors.Is(err, ErrTooLarge) {
+		t.Fatalf("Expected error when line is too long")
+	}
+
+	var perr *ParseError
+	if !errors.As(err, &perr) || perr.Line != 3 || perr.Column != 21 {
+		t.Fatalf("Expected parse error at 3:21 got %v", err)
+	}
+
+	_, err = ParseReader(context.Background(), bytes.NewReader(raw), &ParseOptions{MaxSize: int64(len(raw)), MaxLineLength: 40})
+	if err != nil {
+		t.Fatalf("Unexpected error %s", err)
+	}
+}
+
+func TestParseReaderCanceled(t *testing.T) {
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	_, err := ParseReader(ctx, bytes.NewReader(getPLFile("./testpls/pls1.pls")), nil)
+	if err != context.Canceled {
+		t.Fatalf("Expected context.Canceled got %v", err)
+	}
+}
@@ -0,0 +1,128 @@
+// Part of the Go playlist parser package
+//
+// Copyright 2013 Rafal Zajac rzajac<at>gmail<dot>com. All rights reserved.
+// http://github.com/rzajac/plparser
+//
+// Licensed under the MIT license
+
+package plparser
+
+import (
+	"strings"
+	"sync"
+)
+
+// Format describes a playlist format known to the package.
+// Name and New are required, other fields are optional.
+type Format struct {
+	// Name is the playlist type set on Playlist.Type.
+	Name string
+	// MimeTypes lists content types the format is served with.
+	MimeTypes []string
+	// Extensions lists file extensions including the leading dot.
+	Extensions []string
+	// Detect returns true if playlist is in this format. The header is
+	// the first not empty line of the playlist in lower case. Formats
+	// with no Detect are detected only by content type and extension.
+	Detect func(header string, raw []byte) bool
+	// Plausible returns true if playlist could be in this format even
+	// though Detect does not recognize it. It's used only when no format
+	// recognizes the content and content type or extension points to
+	// this format.
+	Plausible func(header string, raw []byte) bool
+	// New returns new parser for the playlist raw content.
+	New func(raw []byte) Playlister
+}
+
+// registry holds registered playlist formats.
+var registry = struct {
+	sync.RWMutex
+	formats []*Format
+}{}
+
+// RegisterFormat registers playlist format. Formats are tried during
+// detection in the order they were registered. Registering a format
+// with the same name as already registered one replaces it. Panics if
+// the format has no Name or New function.
+func RegisterFormat(f *Format) {
+
+	if f == nil || f.Name == "" || f.New == nil {
+		panic("plparser: RegisterFormat requires format with Name and New")
+	}
+
+	registry.Lock()
+	defer registry.Unlock()
+
+	for idx, rf := range registry.formats {
+		if rf.Name == f.Name {
+			registry.formats[idx] = f
+			return
+		}
+	}
+
+	registry.formats = append(registry.formats, f)
+}
+
+// Formats returns list of registered playlist formats.
+func Formats() []*Format {
+
+	registry.RLock()
+	defer registry.RUnlock()
+
+	return append([]*Format(nil), registry.formats...)
+}
+
+// LookupFormat returns registered format by name or nil if it does not exist.
+func LookupFormat(name string) *Format {
+
+	registry.RLock()
+	defer registry.RUnlock()
+
+	for _, f := range registry.formats {
+		if f.Name == name {
+			return f
+		}
+	}
+
+	return nil
+}
+
+// LookupMimeType returns registered formats served with given content type.
+// Content type parameters like charset are ignored.
+func LookupMimeType(contentType string) []*Format {
+	return lookupFormats(func(f *Format) []string { return f.MimeTypes }, mediaType(contentType))
+}
+
+// LookupExtension returns registered formats using given file extension.
+func LookupExtension(ext string) []*Format {
+	return lookupFormats(func(f *Format) []string { return f.Extensions }, strings.ToLower(ext))
+}
+
+// lookupFormats returns formats having value in the list returned by values.
+func lookupFormats(values func(f *Format) []string, value string) []*Format {
+
+	registry.RLock()
+	defer registry.RUnlock()
+
+	var formats []*Format
+
+	for _, f := range registry.formats {
+		for _, v := range values(f) {
+			if v == value {
+				formats = append(formats, f)
+				break
+			}
+		}
+	}
+
+	return formats
+}
+
+// mediaType returns lowercased content type without parameters.
+func mediaType(contentType string) string {
+	if idx := strings.IndexByte(contentType, ';'); idx != -1 {
+		contentType = contentType[:idx]
+	}
+
+	return strings.ToLower(strings.TrimSpace(contentType))
+}
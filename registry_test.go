@@ -0,0 +1,124 @@
+// Part of the Go playlist parser package
+//
+// Copyright 2013 Rafal Zajac rzajac<at>gmail<dot>com. All rights reserved.
+// http://github.com/rzajac/plparser
+//
+// Licensed under the MIT license
+
+package plparser
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+)
+
+// jsonTestParser parses JSON list of stream URLs.
+type jsonTestParser struct {
+	Diagnostics
+	raw     []byte
+	Streams []*Stream
+}
+
+func (p *jsonTestParser) Parse() error {
+	var urls []string
+	if err := json.Unmarshal(p.raw, &urls); err != nil {
+		return err
+	}
+
+	for idx, u := range urls {
+		s := NewStream(idx + 1)
+		s.Url = u
+		p.Streams = append(p.Streams, s)
+	}
+
+	return nil
+}
+
+func (p *jsonTestParser) GetStreams() []*Stream {
+	return p.Streams
+}
+
+func TestRegisterFormat(t *testing.T) {
+
+	RegisterFormat(&Format{
+		Name:       "jsontest",
+		MimeTypes:  []string{"application/x-jsontest"},
+		Extensions: []string{".jsontest"},
+		Detect: func(header string, raw []byte) bool {
+			return strings.HasPrefix(header, "[\"http")
+		},
+		New: func(raw []byte) Playlister { return &jsonTestParser{raw: raw} },
+	})
+
+	plr := new(PlaylistResp)
+	plr.Raw = []byte(`["http://live1.example.com/", "http://live2.example.com/"]`)
+
+	pl := NewPlaylist(plr)
+	pltype, _ := pl.Parse()
+
+	if pltype != "jsontest" || len(pl.Streams) != 2 {
+		t.Fatalf("Expected jsontest playlist with 2 streams got '%s' with %d", pltype, len(pl.Streams))
+	}
+
+	if _, ok := pl.Parser.(*jsonTestParser); !ok {
+		t.Fatalf("Expected playlist parser to be *jsonTestParser")
+	}
+}
+
+func TestRegisterFormatNoDetect(t *testing.T) {
+
+	RegisterFormat(&Format{
+		Name:      "nodetecttest",
+		MimeTypes: []string{"application/x-nodetecttest"},
+		New:       func(raw []byte) Playlister { return &jsonTestParser{raw: raw} },
+	})
+
+	plr := new(PlaylistResp)
+	plr.Raw = getPLFile("./testpls/pls1.pls")
+	plr.ContentType = "application/x-nodetecttest"
+
+	candidates := DetectFormat(plr)
+	if len(candidates) < 2 || candidates[0].Format != "pls" || candidates[1].Format != "nodetecttest" {
+		t.Fatalf("Expected pls and nodetecttest candidates got %v", candidates)
+	}
+
+	for _, f := range []*Format{nil, {Name: "noparser"}, {New: func(raw []byte) Playlister { return nil }}} {
+		func() {
+			defer func() {
+				if recover() == nil {
+					t.Fatalf("Expected panic registering %+v", f)
+				}
+			}()
+			RegisterFormat(f)
+		}()
+	}
+}
+
+func TestLookupFormat(t *testing.T) {
+
+	for _, name := range []string{"pls", "asf", "asx", "m3u", "hls", "xspf", "wpl", "zpl"} {
+		if f := LookupFormat(name); f == nil || f.Name != name {
+			t.Fatalf("Expected built in format '%s' to be registered", name)
+		}
+	}
+
+	if LookupFormat("unknown") != nil {
+		t.Fatalf("Expected nil for unknown format")
+	}
+
+	formats := LookupMimeType("Audio/X-MpegURL; charset=utf-8")
+	if len(formats) != 1 || formats[0].Name != "m3u" {
+		t.Fatalf("Expected m3u format for audio/x-mpegurl got %v", formats)
+	}
+
+	formats = LookupMimeType("video/x-ms-asf")
+	if len(formats) != 2 {
+		t.Fatalf("Expected 2 formats for video/x-ms-asf got %d", len(formats))
+	}
+
+	formats = LookupExtension(".M3U8")
+	if len(formats) != 1 || formats[0].Name != "hls" {
+		t.Fatalf("Expected hls format for .m3u8 got %v", formats)
+	}
+}
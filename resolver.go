@@ -0,0 +1,203 @@
+// Part of the Go playlist parser package
+//
+// Copyright 2013 Rafal Zajac rzajac<at>gmail<dot>com. All rights reserved.
+// http://github.com/rzajac/plparser
+//
+// Licensed under the MIT license
+
+package plparser
+
+import (
+	"context"
+	"errors"
+	"strings"
+)
+
+// Default limits used by Resolver.
+const (
+	DefaultMaxDepth   = 5
+	DefaultMaxFetches = 20
+)
+
+// ErrFetchBudgetExceeded is returned by Resolver when resolution
+// needs more fetches than allowed.
+var ErrFetchBudgetExceeded = errors.New("Fetch budget exceeded.")
+
+// Resolver resolves nested playlists to the list of media streams.
+type Resolver struct {
+	// Fetcher is used to fetch playlists. If nil a default one is used.
+	Fetcher *Fetcher
+	// MaxDepth is the maximum nesting of playlists. Zero means DefaultMaxDepth.
+	MaxDepth int
+	// MaxFetches is the maximum number of fetches during one resolution.
+	// Zero means DefaultMaxFetches.
+	MaxFetches int
+}
+
+// resolveState holds state of a single resolution.
+type resolveState struct {
+	fetches int
+}
+
+// NewResolver returns new resolver. If fetcher is nil a default one is used.
+func NewResolver(fetcher *Fetcher) *Resolver {
+	if fetcher == nil {
+		fetcher = NewFetcher(nil)
+	}
+
+	r := new(Resolver)
+	r.Fetcher = fetcher
+	r.MaxDepth = DefaultMaxDepth
+	r.MaxFetches = DefaultMaxFetches
+	return r
+}
+
+// Resolve fetches playlist from URL and follows every stream URL which
+// turns out to be a playlist itself. Returns flattened list of media
+// streams each with Chain set to the list of playlists it came through.
+// If URL is not a playlist it's returned as the only stream.
+//
+// When the fetch budget is exceeded streams resolved so far are
+// returned together with ErrFetchBudgetExceeded.
+func (r *Resolver) Resolve(ctx context.Context, url string) ([]*Stream, error) {
+
+	st := new(resolveState)
+
+	streams, err := r.resolve(ctx, url, nil, st)
+	if err != nil {
+		return streams, err
+	}
+
+	if streams == nil {
+		stream := NewStream(1)
+		stream.Url = url
+		streams = []*Stream{stream}
+	}
+
+	return streams, nil
+}
+
+// resolve resolves streams of a playlist at the URL. Returns nil streams
+// if the URL does not point to a playlist.
+func (r *Resolver) resolve(ctx context.Context, url string, chain []string, st *resolveState) ([]*Stream, error) {
+
+	maxFetches := r.MaxFetches
+	if maxFetches <= 0 {
+		maxFetches = DefaultMaxFetches
+	}
+
+	if st.fetches >= maxFetches {
+		return nil, ErrFetchBudgetExceeded
+	}
+	st.fetches++
+
+	plr, err := r.fetcher().Fetch(ctx, url)
+	if err != nil {
+		return nil, err
+	}
+
+	if !plr.IsPotentialPlaylist() {
+		return nil, nil
+	}
+
+	pl := NewPlaylist(plr)
+	pl.AsxLoader = r.asxLoader(ctx, st)
+	if _, err = pl.Parse(); err != nil || !pl.IsDetected() {
+		return nil, nil
+	}
+
+	// Nested playlist with no streams is treated as a media stream
+	if len(pl.Streams) == 0 && len(chain) > 0 {
+		return nil, nil
+	}
+
+	maxDepth := r.MaxDepth
+	if maxDepth <= 0 {
+		maxDepth = DefaultMaxDepth
+	}
+
+	chain = append(chain[:len(chain):len(chain)], url)
+	streams := make([]*Stream, 0, len(pl.Streams))
+
+	for _, s := range pl.Streams {
+
+		// Skip streams pointing back to playlists we came through
+		if inChain(s.Url, chain) {
+			continue
+		}
+
+		var nested []*Stream
+
+		if len(chain) < maxDepth && isHttpUrl(s.Url) {
+			nested, err = r.resolve(ctx, s.Url, chain, st)
+
+			if err == ErrFetchBudgetExceeded {
+				return append(streams, nested...), err
+			}
+
+			if ctx.Err() != nil {
+				return append(streams, nested...), contextError(ctx.Err())
+			}
+		}
+
+		// Errors of nested fetches are ignored,
+		// we treat such streams as media streams
+		if nested == nil {
+			stream := s.makeCopy()
+			stream.Chain = append(append([]string(nil), chain...), s.Chain...)
+			nested = []*Stream{stream}
+		}
+
+		streams = append(streams, nested...)
+	}
+
+	return streams, nil
+}
+
+// asxLoader returns ENTRYREF loader which counts its fetches
+// towards the fetch budget of the resolution.
+func (r *Resolver) asxLoader(ctx context.Context, st *resolveState) AsxLoader {
+
+	load := NewFetcherAsxLoader(ctx, r.fetcher())
+
+	return func(url string) ([]byte, error) {
+
+		maxFetches := r.MaxFetches
+		if maxFetches <= 0 {
+			maxFetches = DefaultMaxFetches
+		}
+
+		if st.fetches >= maxFetches {
+			return nil, ErrFetchBudgetExceeded
+		}
+		st.fetches++
+
+		return load(url)
+	}
+}
+
+// fetcher returns resolver's fetcher or the default one if not set.
+func (r *Resolver) fetcher() *Fetcher {
+	if r.Fetcher == nil {
+		return NewFetcher(nil)
+	}
+
+	return r.Fetcher
+}
+
+// inChain returns true if url is one of the URLs in the chain.
+func inChain(url string, chain []string) bool {
+	for _, c := range chain {
+		if c == url {
+			return true
+		}
+	}
+
+	return false
+}
+
+// isHttpUrl returns true if url can be fetched with HTTP client.
+func isHttpUrl(url string) bool {
+	url = strings.ToLower(url)
+	return strings.HasPrefix(url, "http://") || strings.HasPrefix(url, "https://")
+}
@@ -0,0 +1,242 @@
+// Part of the Go playlist parser package
+//
+// Copyright 2013 Rafal Zajac rzajac<at>gmail<dot>com. All rights reserved.
+// http://github.com/rzajac/plparser
+//
+// Licensed under the MIT license
+
+package plparser
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+// newResolverTestServer returns test server serving playlists.
+// The {URL} in playlists is replaced with the server URL.
+func newResolverTestServer() *httptest.Server {
+
+	var playlists = map[string]string{
+		"/a.pls":     "[playlist]\nFile1={URL}/b.m3u\nTitle1=B\nFile2={URL}/stream2\n",
+		"/b.m3u":     "{URL}/c.asx\n",
+		"/c.asx":     "<asx version=\"3.0\"><entry><title>C</title><ref href=\"{URL}/stream1\"/></entry></asx>",
+		"/wrap.asx":  "<asx version=\"3.0\"><entryref href=\"c.asx\"/></asx>",
+		"/loop1.m3u": "{URL}/loop2.m3u\n{URL}/stream1\n",
+		"/loop2.m3u": "{URL}/loop1.m3u\n",
+		"/outer.m3u": "{URL}/two.m3u\n",
+		"/two.m3u":   "{URL}/stream1\n{URL}/stream2\n",
+		"/deep.m3u":  "{URL}/slow.m3u\n",
+		"/empty.pls": "[playlist]\nFile1={URL}/empty.m3u\nTitle1=Empty\nFile2={URL}/stream2\n",
+		"/empty.m3u": "#EXTM3U\n# No streams\n",
+		"/slow.m3u":  "{URL}/stream1\n",
+	}
+
+	var ts *httptest.Server
+
+	ts = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+
+		if r.URL.Path == "/slow.m3u" {
+			time.Sleep(200 * time.Millisecond)
+		}
+
+		if pl, ok := playlists[r.URL.Path]; ok {
+			w.Header().Set("Content-Type", "text/plain")
+			w.Write([]byte(strings.Replace(pl, "{URL}", ts.URL, -1)))
+			return
+		}
+
+		if strings.HasPrefix(r.URL.Path, "/stream") {
+			w.Header().Set("Content-Type", "audio/mpeg")
+			w.Write([]byte("ID3\x03\x00\x00\x00\x00\x00\x00"))
+			return
+		}
+
+		http.NotFound(w, r)
+	}))
+
+	return ts
+}
+
+func TestResolverNested(t *testing.T) {
+
+	ts := newResolverTestServer()
+	defer ts.Close()
+
+	streams, err := NewResolver(NewFetcher(ts.Client())).Resolve(context.Background(), ts.URL+"/a.pls")
+	if err != nil {
+		t.Fatalf("Unexpected error %s", err)
+	}
+
+	if len(streams) != 2 {
+		t.Fatalf("Expected 2 streams got %d", len(streams))
+	}
+
+	var expected = map[string][]string{
+		ts.URL + "/stream1": {ts.URL + "/a.pls", ts.URL + "/b.m3u", ts.URL + "/c.asx"},
+		ts.URL + "/stream2": {ts.URL + "/a.pls"},
+	}
+
+	for _, s := range streams {
+		if !reflect.DeepEqual(expected[s.Url], s.Chain) {
+			t.Fatalf("Expected stream %s chain %v == %v", s.Url, expected[s.Url], s.Chain)
+		}
+	}
+}
+
+func TestResolverEntryRef(t *testing.T) {
+
+	ts := newResolverTestServer()
+	defer ts.Close()
+
+	streams, err := NewResolver(NewFetcher(ts.Client())).Resolve(context.Background(), ts.URL+"/wrap.asx")
+	if err != nil {
+		t.Fatalf("Unexpected error %s", err)
+	}
+
+	expected := []string{ts.URL + "/wrap.asx", ts.URL + "/c.asx"}
+
+	if len(streams) != 1 || streams[0].Url != ts.URL+"/stream1" || !reflect.DeepEqual(streams[0].Chain, expected) {
+		t.Fatalf("Expected stream of referenced playlist with chain %v got %+v", expected, streams)
+	}
+}
+
+func TestResolverMediaUrl(t *testing.T) {
+
+	ts := newResolverTestServer()
+	defer ts.Close()
+
+	streams, err := NewResolver(nil).Resolve(context.Background(), ts.URL+"/stream1")
+	if err != nil {
+		t.Fatalf("Unexpected error %s", err)
+	}
+
+	if len(streams) != 1 || streams[0].Url != ts.URL+"/stream1" || streams[0].Chain != nil {
+		t.Fatalf("Expected URL to be returned as media stream got %+v", streams)
+	}
+}
+
+func TestResolverLoop(t *testing.T) {
+
+	ts := newResolverTestServer()
+	defer ts.Close()
+
+	streams, err := NewResolver(nil).Resolve(context.Background(), ts.URL+"/loop1.m3u")
+	if err != nil {
+		t.Fatalf("Unexpected error %s", err)
+	}
+
+	if len(streams) != 1 || streams[0].Url != ts.URL+"/stream1" {
+		t.Fatalf("Expected only media stream got %+v", streams)
+	}
+}
+
+func TestResolverLimits(t *testing.T) {
+
+	ts := newResolverTestServer()
+	defer ts.Close()
+
+	r := NewResolver(nil)
+	r.MaxDepth = 1
+
+	streams, err := r.Resolve(context.Background(), ts.URL+"/a.pls")
+	if err != nil {
+		t.Fatalf("Unexpected error %s", err)
+	}
+
+	if len(streams) != 2 {
+		t.Fatalf("Expected 2 streams got %d", len(streams))
+	}
+
+	for _, s := range streams {
+		if s.Url == ts.URL+"/b.m3u" && s.Title != "B" {
+			t.Fatalf("Expected stream %s title 'B' == '%s'", s.Url, s.Title)
+		}
+
+		if s.Url != ts.URL+"/b.m3u" && s.Url != ts.URL+"/stream2" {
+			t.Fatalf("Expected nested playlists not to be followed got %s", s.Url)
+		}
+	}
+
+	r = NewResolver(nil)
+	r.MaxFetches = 2
+
+	if _, err = r.Resolve(context.Background(), ts.URL+"/a.pls"); err != ErrFetchBudgetExceeded {
+		t.Fatalf("Expected ErrFetchBudgetExceeded got %v", err)
+	}
+}
+
+func TestResolverBudgetPartial(t *testing.T) {
+
+	ts := newResolverTestServer()
+	defer ts.Close()
+
+	r := NewResolver(nil)
+	r.MaxFetches = 3
+
+	streams, err := r.Resolve(context.Background(), ts.URL+"/outer.m3u")
+	if err != ErrFetchBudgetExceeded {
+		t.Fatalf("Expected ErrFetchBudgetExceeded got %v", err)
+	}
+
+	if len(streams) != 1 || streams[0].Url != ts.URL+"/stream1" {
+		t.Fatalf("Expected streams resolved before budget was exceeded got %v", streams)
+	}
+
+	expected := []string{ts.URL + "/outer.m3u", ts.URL + "/two.m3u"}
+	if !reflect.DeepEqual(streams[0].Chain, expected) {
+		t.Fatalf("Expected chain %v got %v", expected, streams[0].Chain)
+	}
+}
+
+func TestResolverTimeout(t *testing.T) {
+
+	ts := newResolverTestServer()
+	defer ts.Close()
+
+	r := NewResolver(nil)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+	defer cancel()
+
+	if _, err := r.Resolve(ctx, ts.URL+"/deep.m3u"); !errors.Is(err, ErrTimeout) {
+		t.Fatalf("Expected ErrTimeout got %v", err)
+	}
+}
+
+func TestResolverNilFetcher(t *testing.T) {
+
+	ts := newResolverTestServer()
+	defer ts.Close()
+
+	r := new(Resolver)
+
+	streams, err := r.Resolve(context.Background(), ts.URL+"/a.pls")
+	if err != nil {
+		t.Fatalf("Unexpected error %s", err)
+	}
+
+	if len(streams) != 2 {
+		t.Fatalf("Expected 2 streams got %d", len(streams))
+	}
+}
+
+func TestResolverEmptyNested(t *testing.T) {
+
+	ts := newResolverTestServer()
+	defer ts.Close()
+
+	streams, err := NewResolver(nil).Resolve(context.Background(), ts.URL+"/empty.pls")
+	if err != nil {
+		t.Fatalf("Unexpected error %s", err)
+	}
+
+	if len(streams) != 2 || streams[0].Url != ts.URL+"/empty.m3u" || streams[0].Title != "Empty" || streams[1].Url != ts.URL+"/stream2" {
+		t.Fatalf("Expected playlist with no streams to be kept as stream got %v", streams)
+	}
+}
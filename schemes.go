@@ -0,0 +1,138 @@
+// Part of the Go playlist parser package
+//
+// Copyright 2013 Rafal Zajac rzajac<at>gmail<dot>com. All rights reserved.
+// http://github.com/rzajac/plparser
+//
+// Licensed under the MIT license
+
+package plparser
+
+import (
+	"net/url"
+	"strings"
+)
+
+// DefaultSchemes are stream URL schemes accepted by default scheme policy.
+var DefaultSchemes = []string{
+	"http", "https",
+	"mms", "mmsh", "mmst", "mmsu",
+	"rtsp", "rtsps", "rtspu",
+	"rtmp", "rtmps", "rtmpe", "rtmpt", "rtmpte",
+	"rtp", "udp",
+	"icy", "icyx",
+	"file",
+}
+
+// DefaultSchemeAlternatives are schemes tried instead of a scheme when
+// the stream can not be played with it. MMS was replaced by MMS over HTTP
+// and Shoutcast ICY streams are served over HTTP.
+var DefaultSchemeAlternatives = map[string][]string{
+	"mms":  {"mmsh", "http"},
+	"mmsh": {"http"},
+	"icy":  {"http"},
+	"icyx": {"http"},
+}
+
+// SchemePolicy decides which stream URL schemes are accepted.
+type SchemePolicy struct {
+	// Schemes lists accepted URL schemes in lower case.
+	Schemes []string
+	// Alternatives maps scheme to schemes which may be used instead of it.
+	// Stream URLs with those schemes are set in Stream.Alternatives.
+	Alternatives map[string][]string
+	// RejectUnknown drops streams with schemes not in Schemes and
+	// references which are not URLs after resolving against the base
+	// URL. When false such streams are kept and reported by a diagnostic.
+	RejectUnknown bool
+}
+
+// NewSchemePolicy returns new scheme policy accepting DefaultSchemes.
+// The policy has its own copy of DefaultSchemes and
+// DefaultSchemeAlternatives so changing it does not affect other policies.
+func NewSchemePolicy() *SchemePolicy {
+	sp := new(SchemePolicy)
+	sp.Schemes = append([]string(nil), DefaultSchemes...)
+	sp.Alternatives = make(map[string][]string, len(DefaultSchemeAlternatives))
+
+	for scheme, alts := range DefaultSchemeAlternatives {
+		sp.Alternatives[scheme] = append([]string(nil), alts...)
+	}
+
+	return sp
+}
+
+// defaultSchemePolicy is used by parsers with no scheme policy set.
+var defaultSchemePolicy = NewSchemePolicy()
+
+// SchemePolicySetter is implemented by parsers which check stream URL
+// schemes. All built in parsers implement it.
+type SchemePolicySetter interface {
+	// SetSchemePolicy sets the scheme policy. Must be called before Parse.
+	SetSchemePolicy(sp *SchemePolicy)
+}
+
+// IsAccepted returns true if scheme is one of accepted schemes.
+func (sp *SchemePolicy) IsAccepted(scheme string) bool {
+
+	scheme = strings.ToLower(scheme)
+
+	for _, s := range sp.Schemes {
+		if s == scheme {
+			return true
+		}
+	}
+
+	return false
+}
+
+// Normalize returns URL with lowercased scheme and list of the URL
+// alternatives using other schemes.
+func (sp *SchemePolicy) Normalize(u *url.URL) (normalized string, alternatives []string) {
+
+	u.Scheme = strings.ToLower(u.Scheme)
+	normalized = u.String()
+
+	for _, scheme := range sp.Alternatives[u.Scheme] {
+		alt := *u
+		alt.Scheme = scheme
+		alternatives = append(alternatives, alt.String())
+	}
+
+	return
+}
+
+// checkStream checks the stream URL against scheme policy. It normalizes
+// the URL and reports references which are not URLs or have not accepted
+// scheme. Returns false if the stream must be dropped.
+func (r *UrlResolver) checkStream(d *Diagnostics, s *Stream, line, column int, text string) bool {
+
+	sp := r.schemePolicy
+	if sp == nil {
+		sp = defaultSchemePolicy
+	}
+
+	u, err := url.Parse(s.Url)
+	if err != nil || u.Scheme == "" {
+		if sp.RejectUnknown {
+			d.AddDiagnostic(line, column, SeverityError, RULE_NOT_URL, "Rejected not a URL", text)
+			return false
+		}
+
+		d.AddDiagnostic(line, column, SeverityWarning, RULE_NOT_URL, "Not a URL", text)
+		return true
+	}
+
+	if !sp.IsAccepted(u.Scheme) {
+		if sp.RejectUnknown {
+			d.AddDiagnostic(line, column, SeverityError, RULE_UNKNOWN_SCHEME, "Rejected URL scheme "+u.Scheme, text)
+			return false
+		}
+
+		d.AddDiagnostic(line, column, SeverityWarning, RULE_UNKNOWN_SCHEME, "Unknown URL scheme "+u.Scheme, text)
+		return true
+	}
+
+	s.Url, s.Alternatives = sp.Normalize(u)
+
+	return true
+}
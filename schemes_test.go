@@ -0,0 +1,156 @@
+// Part of the Go playlist parser package
+//
+// Copyright 2013 Rafal Zajac rzajac<at>gmail<dot>com. All rights reserved.
+// http://github.com/rzajac/plparser
+//
+// Licensed under the MIT license
+
+package plparser
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestIsUrlSchemes(t *testing.T) {
+
+	var tests = []struct {
+		text     string
+		expected bool
+	}{
+		{"http://ex.com/", true},
+		{"HTTPS://ex.com/", true},
+		{"rtsp://ex.com:554/live", true},
+		{"rtmp://ex.com/app/stream", true},
+		{"udp://@239.0.0.1:1234", true},
+		{"rtp://239.0.0.1:5004", true},
+		{"icyx://ex.com:8000/", true},
+		{"mmsh://ex.com/live", true},
+		{"file:///home/music/a.mp3", true},
+		{"httpfoo", false},
+		{"httpfoo://ex.com/", false},
+		{"stream.mp3", false},
+	}
+
+	for _, test := range tests {
+		if isUrl(test.text) != test.expected {
+			t.Fatalf("Expected isUrl('%s') to be %v", test.text, test.expected)
+		}
+	}
+}
+
+func TestSchemePolicyStreams(t *testing.T) {
+
+	raw := []byte("#EXTM3U\nrtsp://ex.com/live\nudp://@239.0.0.1:1234\nMMS://ex.com/radio\nfoo://ex.com/\n")
+
+	plr := new(PlaylistResp)
+	plr.Raw = raw
+
+	pl := NewPlaylist(plr)
+	if _, err := pl.Parse(); err != nil {
+		t.Fatalf("Unexpected error %s", err)
+	}
+
+	if len(pl.Streams) != 4 {
+		t.Fatalf("Expected 4 streams got %d", len(pl.Streams))
+	}
+
+	if pl.Streams[0].Url != "rtsp://ex.com/live" || pl.Streams[1].Url != "udp://@239.0.0.1:1234" {
+		t.Fatalf("Unexpected streams %v %v", pl.Streams[0], pl.Streams[1])
+	}
+
+	mms := pl.Streams[2]
+	if mms.Url != "mms://ex.com/radio" || !reflect.DeepEqual(mms.Alternatives, []string{"mmsh://ex.com/radio", "http://ex.com/radio"}) {
+		t.Fatalf("Unexpected mms stream %s %v", mms.Url, mms.Alternatives)
+	}
+
+	if len(pl.Diagnostics) != 1 || pl.Diagnostics[0].Rule != RULE_UNKNOWN_SCHEME || pl.Diagnostics[0].Line != 5 {
+		t.Fatalf("Expected unknown scheme diagnostic got %v", pl.Diagnostics)
+	}
+}
+
+func TestSchemePolicyReject(t *testing.T) {
+
+	sp := NewSchemePolicy()
+	sp.Schemes = []string{"rtsp", "udp"}
+	sp.RejectUnknown = true
+
+	plr := new(PlaylistResp)
+	plr.Raw = []byte("[playlist]\nFile1=http://ex.com/\nFile2=rtsp://ex.com/live\nNumberOfEntries=2\nVersion=2\n")
+
+	pl := NewPlaylist(plr)
+	pl.SchemePolicy = sp
+	if _, err := pl.Parse(); err != nil {
+		t.Fatalf("Unexpected error %s", err)
+	}
+
+	if len(pl.Streams) != 1 || pl.Streams[0].Url != "rtsp://ex.com/live" {
+		t.Fatalf("Expected only rtsp stream got %v", pl.Streams)
+	}
+
+	if len(pl.Diagnostics) != 1 || pl.Diagnostics[0].Rule != RULE_UNKNOWN_SCHEME || pl.Diagnostics[0].Severity != SeverityError {
+		t.Fatalf("Expected rejected scheme diagnostic got %v", pl.Diagnostics)
+	}
+}
+
+func TestSchemePolicyRejectNotUrl(t *testing.T) {
+
+	sp := NewSchemePolicy()
+	sp.RejectUnknown = true
+
+	plr := new(PlaylistResp)
+	plr.Raw = []byte("#EXTM3U\nhttpfoo\nhello world\nhttp://ex.com/live\n")
+
+	pl := NewPlaylist(plr)
+	pl.SchemePolicy = sp
+	if _, err := pl.Parse(); err != nil {
+		t.Fatalf("Unexpected error %s", err)
+	}
+
+	if len(pl.Streams) != 1 || pl.Streams[0].Url != "http://ex.com/live" {
+		t.Fatalf("Expected only http stream got %v", pl.Streams)
+	}
+
+	if len(pl.Diagnostics) != 2 {
+		t.Fatalf("Expected 2 diagnostics got %v", pl.Diagnostics)
+	}
+
+	for _, d := range pl.Diagnostics {
+		if d.Rule != RULE_NOT_URL || d.Severity != SeverityError {
+			t.Fatalf("Expected rejected not URL diagnostic got %v", d)
+		}
+	}
+
+	// Relative references resolved against the playlist location are kept
+	plr = new(PlaylistResp)
+	plr.Url = "http://ex.com/lists/radio.m3u"
+	plr.Raw = []byte("#EXTM3U\nstream.mp3\n")
+
+	pl = NewPlaylist(plr)
+	pl.SchemePolicy = sp
+	pl.Parse()
+
+	if len(pl.Streams) != 1 || pl.Streams[0].Url != "http://ex.com/lists/stream.mp3" {
+		t.Fatalf("Expected resolved stream got %v", pl.Streams)
+	}
+}
+
+func TestNewSchemePolicyCopy(t *testing.T) {
+
+	sp := NewSchemePolicy()
+	sp.Schemes[0] = "changed"
+	sp.Alternatives["mms"][0] = "changed"
+	sp.Alternatives["rtsp"] = []string{"http"}
+
+	if DefaultSchemes[0] == "changed" || DefaultSchemeAlternatives["mms"][0] == "changed" {
+		t.Fatalf("Expected defaults not to change got %v %v", DefaultSchemes, DefaultSchemeAlternatives)
+	}
+
+	if _, ok := DefaultSchemeAlternatives["rtsp"]; ok {
+		t.Fatalf("Expected no rtsp alternatives in defaults got %v", DefaultSchemeAlternatives)
+	}
+
+	if other := NewSchemePolicy(); other.Schemes[0] == "changed" || other.Alternatives["mms"][0] == "changed" {
+		t.Fatalf("Expected new policy with defaults got %v %v", other.Schemes, other.Alternatives)
+	}
+}
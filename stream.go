@@ -9,18 +9,53 @@ package plparser
 
 import (
 	"reflect"
+	"time"
 )
 
+// LiveDuration is a duration of a stream with no end (live stream).
+const LiveDuration time.Duration = -1
+
 // Stream is a struct representing a stream.
 type Stream struct {
-	Index       int    `json:"index"`
-	Title       string `json:"title"`
-	Description string `json:"descr"`
-	Logo        string `json:"logo"`
-	Author      string `json:"author"`
-	Copyright   string `json:"copyright"`
-	MoreInfo    string `json:"info"`
-	Url         string `json:"url"`
+	Index       int           `json:"index"`
+	Title       string        `json:"title"`
+	Description string        `json:"descr"`
+	Logo        string        `json:"logo"`
+	Author      string        `json:"author"`
+	Copyright   string        `json:"copyright"`
+	MoreInfo    string        `json:"info"`
+	Album       string        `json:"album"`
+	Genre       string        `json:"genre"`
+	Location    string        `json:"location"`
+	Homepage    string        `json:"homepage"`
+	HTMLView    string        `json:"htmlview"`
+	Duration    time.Duration `json:"duration"`
+	Url         string        `json:"url"`
+
+	// OriginalUrl is the stream reference as found in the playlist
+	// before it was resolved against the playlist location to Url.
+	OriginalUrl string `json:"orig_url,omitempty"`
+
+	// Alternatives are the stream URL with other schemes which may be
+	// used if Url can not be played, for example mmsh and http for mms.
+	Alternatives []string `json:"alts,omitempty"`
+
+	// Fallbacks are URLs of the same stream from other sources in order
+	// they should be tried if Url can not be played: other REF elements
+	// of ASX entry, other locations of XSPF track or PLS mirrors.
+	Fallbacks []string `json:"fallbacks,omitempty"`
+
+	// Attributes holds additional key value pairs found in a playlist
+	// for example IPTV attributes of #EXTINF tag (tvg-id, group-title)
+	// or ASX PARAM elements.
+	Attributes map[string]string `json:"attrs,omitempty"`
+
+	// Chain is the list of playlist URLs the stream was resolved through.
+	Chain []string `json:"chain,omitempty"`
+
+	// Repeat is the COUNT of the innermost ASX REPEAT element the stream
+	// is in, -1 if it's repeated indefinitely and 0 if it's not repeated.
+	Repeat int `json:"repeat,omitempty"`
 
 	// Some unexported properties to handle parsing
 	// of various playlists.
@@ -47,7 +82,34 @@ func (s *Stream) makeCopy() *Stream {
 	str.Author = s.Author
 	str.Copyright = s.Copyright
 	str.MoreInfo = s.MoreInfo
+	str.Album = s.Album
+	str.Genre = s.Genre
+	str.Location = s.Location
+	str.Homepage = s.Homepage
+	str.HTMLView = s.HTMLView
+	str.Duration = s.Duration
 	str.Url = s.Url
+	str.OriginalUrl = s.OriginalUrl
+	str.Repeat = s.Repeat
+
+	if s.Attributes != nil {
+		str.Attributes = make(map[string]string, len(s.Attributes))
+		for k, v := range s.Attributes {
+			str.Attributes[k] = v
+		}
+	}
+
+	if s.Alternatives != nil {
+		str.Alternatives = append([]string(nil), s.Alternatives...)
+	}
+
+	if s.Fallbacks != nil {
+		str.Fallbacks = append([]string(nil), s.Fallbacks...)
+	}
+
+	if s.Chain != nil {
+		str.Chain = append([]string(nil), s.Chain...)
+	}
 
 	return str
 }
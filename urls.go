@@ -0,0 +1,92 @@
+// Part of the Go playlist parser package
+//
+// Copyright 2013 Rafal Zajac rzajac<at>gmail<dot>com. All rights reserved.
+// http://github.com/rzajac/plparser
+//
+// Licensed under the MIT license
+
+package plparser
+
+import (
+	"net/url"
+)
+
+// BaseUrlSetter is implemented by parsers which resolve relative stream
+// references against the playlist location. All built in parsers implement it.
+type BaseUrlSetter interface {
+	// SetBaseUrl sets the location of the playlist. Must be called before Parse.
+	SetBaseUrl(base string)
+}
+
+// UrlResolver resolves stream references found in a playlist.
+// Parsers embed it to implement BaseUrlSetter and SchemePolicySetter interfaces.
+type UrlResolver struct {
+	baseUrl      string
+	schemePolicy *SchemePolicy
+}
+
+// SetBaseUrl sets the location of the playlist.
+func (r *UrlResolver) SetBaseUrl(base string) {
+	r.baseUrl = base
+}
+
+// SetSchemePolicy sets the policy for stream URL schemes.
+// Nil means the default policy.
+func (r *UrlResolver) SetSchemePolicy(sp *SchemePolicy) {
+	r.schemePolicy = sp
+}
+
+// BaseUrl returns the location of the playlist.
+func (r *UrlResolver) BaseUrl() string {
+	return r.baseUrl
+}
+
+// resolveUrl resolves stream reference against bases found in the
+// playlist (ASX BASE, XSPF xml:base) and then the playlist location.
+func (r *UrlResolver) resolveUrl(ref string, bases ...string) string {
+	return resolveUrl(ref, append(bases, r.baseUrl)...)
+}
+
+// resolveUrl resolves reference against the list of bases. The bases are
+// ordered from the innermost to the outermost one and each relative base
+// is resolved against the next one. Returns reference unchanged if it's
+// absolute or there is no base to resolve it against.
+func resolveUrl(ref string, bases ...string) string {
+
+	u, err := url.Parse(ref)
+	if ref == "" || err != nil || u.IsAbs() {
+		return ref
+	}
+
+	var base *url.URL
+
+	for i := len(bases) - 1; i >= 0; i-- {
+
+		if bases[i] == "" {
+			continue
+		}
+
+		b, err := url.Parse(bases[i])
+		if err != nil {
+			continue
+		}
+
+		if base != nil {
+			b = base.ResolveReference(b)
+		}
+
+		base = b
+	}
+
+	if base == nil {
+		return ref
+	}
+
+	return base.ResolveReference(u).String()
+}
+
+// isAbsUrl returns true if text is an absolute URL.
+func isAbsUrl(text string) bool {
+	u, err := url.Parse(text)
+	return err == nil && u.IsAbs()
+}
@@ -0,0 +1,85 @@
+// Part of the Go playlist parser package
+//
+// Copyright 2013 Rafal Zajac rzajac<at>gmail<dot>com. All rights reserved.
+// http://github.com/rzajac/plparser
+//
+// Licensed under the MIT license
+
+package plparser
+
+import (
+	"testing"
+)
+
+func TestResolveUrl(t *testing.T) {
+
+	var tests = []struct {
+		ref      string
+		bases    []string
+		expected string
+	}{
+		{"http://ex.com/a.mp3", []string{"http://other.com/"}, "http://ex.com/a.mp3"},
+		{"stream.mp3", []string{"http://ex.com/live/pl.m3u"}, "http://ex.com/live/stream.mp3"},
+		{"/live/aac", []string{"http://ex.com/radio/pl.m3u"}, "http://ex.com/live/aac"},
+		{"../aac", []string{"http://ex.com/radio/live/pl.m3u"}, "http://ex.com/radio/aac"},
+		{"//cdn.ex.com/a.ts", []string{"https://ex.com/pl.m3u8"}, "https://cdn.ex.com/a.ts"},
+		{"a.mp3", []string{"http://base.com/dir/", "http://ex.com/pl.asx"}, "http://base.com/dir/a.mp3"},
+		{"a.mp3", []string{"media/", "http://ex.com/pl/list.xspf"}, "http://ex.com/pl/media/a.mp3"},
+		{"a.mp3", []string{"", "http://ex.com/pl.pls"}, "http://ex.com/a.mp3"},
+		{"a.mp3", []string{"", ""}, "a.mp3"},
+		{"a.mp3", nil, "a.mp3"},
+		{"", []string{"http://ex.com/"}, ""},
+	}
+
+	for _, test := range tests {
+		if got := resolveUrl(test.ref, test.bases...); got != test.expected {
+			t.Fatalf("Expected %s got %s for %s %v", test.expected, got, test.ref, test.bases)
+		}
+	}
+}
+
+func TestRelativeStreams(t *testing.T) {
+
+	var tests = []struct {
+		name     string
+		raw      string
+		url      string
+		original string
+		expected string
+	}{
+		{"m3u", "#EXTM3U\n#EXTINF:-1,One\nstream.mp3\n", "http://ex.com/radio/pl.m3u", "stream.mp3", "http://ex.com/radio/stream.mp3"},
+		{"m3u absolute path", "#EXTM3U\n/live/aac\n", "http://ex.com/radio/pl.m3u", "/live/aac", "http://ex.com/live/aac"},
+		{"hls", "#EXTM3U\n#EXT-X-TARGETDURATION:10\n#EXTINF:10,\nseg1.ts\n", "http://cdn.ex.com/hls/index.m3u8", "seg1.ts", "http://cdn.ex.com/hls/seg1.ts"},
+		{"pls", "[playlist]\nFile1=stream.mp3\nNumberOfEntries=1\nVersion=2\n", "http://ex.com/pl.pls", "stream.mp3", "http://ex.com/stream.mp3"},
+		{"asx base", "<asx version=\"3.0\">\n<base href=\"http://base.ex.com/media\"/>\n<entry><ref href=\"a.mp3\"/></entry>\n</asx>", "http://ex.com/pl.asx", "a.mp3", "http://base.ex.com/media/a.mp3"},
+		{"asx entry base", "<asx version=\"3.0\">\n<base href=\"http://base.ex.com/\"/>\n<entry><base href=\"http://entry.ex.com/\"/><ref href=\"a.mp3\"/></entry>\n</asx>", "http://ex.com/pl.asx", "a.mp3", "http://entry.ex.com/a.mp3"},
+		{"asx no base", "<asx version=\"3.0\">\n<entry><ref href=\"a.mp3\"/></entry>\n</asx>", "http://ex.com/pl/pl.asx", "a.mp3", "http://ex.com/pl/a.mp3"},
+		{"xspf base", "<playlist version=\"1\" xmlns=\"http://xspf.org/ns/0/\" xml:base=\"http://base.ex.com/music/\"><trackList><track><location>a.ogg</location></track></trackList></playlist>", "http://ex.com/pl.xspf", "a.ogg", "http://base.ex.com/music/a.ogg"},
+		{"xspf track base", "<playlist version=\"1\" xmlns=\"http://xspf.org/ns/0/\" xml:base=\"http://base.ex.com/music/\"><trackList><track xml:base=\"rock/\"><location>a.ogg</location></track></trackList></playlist>", "http://ex.com/pl.xspf", "a.ogg", "http://base.ex.com/music/rock/a.ogg"},
+	}
+
+	for _, test := range tests {
+
+		plr := new(PlaylistResp)
+		plr.Raw = []byte(test.raw)
+		plr.Url = test.url
+
+		pl := NewPlaylist(plr)
+		if _, err := pl.Parse(); err != nil {
+			t.Fatalf("Unexpected error %s (%s)", err, test.name)
+		}
+
+		if len(pl.Streams) != 1 {
+			t.Fatalf("Expected 1 stream got %d (%s)", len(pl.Streams), test.name)
+		}
+
+		s := pl.Streams[0]
+		if s.Url != test.expected || s.OriginalUrl != test.original {
+			t.Fatalf("Expected %s (%s) got %s (%s) (%s)", test.expected, test.original, s.Url, s.OriginalUrl, test.name)
+		}
+
+		if len(pl.Diagnostics) != 0 {
+			t.Fatalf("Expected no diagnostics got %v (%s)", pl.Diagnostics, test.name)
+		}
+	}
+}
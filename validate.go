@@ -0,0 +1,111 @@
+// Part of the Go playlist parser package
+//
+// Copyright 2013 Rafal Zajac rzajac<at>gmail<dot>com. All rights reserved.
+// http://github.com/rzajac/plparser
+//
+// Licensed under the MIT license
+
+package plparser
+
+import (
+	"sort"
+	"strconv"
+)
+
+// Rule identifiers of diagnostics and validation findings.
+const (
+	RULE_READ_ERROR                  = "read-error"
+	RULE_UNKNOWN_FORMAT              = "unknown-format"
+	RULE_NOT_URL                     = "not-url"
+	RULE_UNKNOWN_SCHEME              = "unknown-scheme"
+	RULE_MISSING_EQUALS              = "missing-equals"
+	RULE_DUPLICATE_INDEX             = "duplicate-index"
+	RULE_INDEX_GAP                   = "index-gap"
+	RULE_MALFORMED_XML               = "malformed-xml"
+	RULE_PLS_ENTRIES_MISMATCH        = "pls-entries-mismatch"
+	RULE_PLS_MISSING_ENTRIES         = "pls-missing-entries"
+	RULE_PLS_MISSING_VERSION         = "pls-missing-version"
+	RULE_ASX_MISSING_VERSION         = "asx-missing-version"
+	RULE_ASX_REF_OUTSIDE_ENTRY       = "asx-ref-outside-entry"
+	RULE_ASX_UNCLOSED_ENTRY          = "asx-unclosed-entry"
+	RULE_ASX_ENTRYREF_FAILED         = "asx-entryref-failed"
+	RULE_ASX_ENTRYREF_CYCLE          = "asx-entryref-cycle"
+	RULE_EXTINF_WITHOUT_URI          = "extinf-without-uri"
+	RULE_HLS_MISSING_EXTM3U          = "hls-missing-extm3u"
+	RULE_HLS_TAG_ORDER               = "hls-tag-order"
+	RULE_HLS_MISSING_TARGET_DURATION = "hls-missing-target-duration"
+	RULE_HLS_MISSING_BANDWIDTH       = "hls-missing-bandwidth"
+	RULE_HLS_STREAM_INF_WITHOUT_URI  = "hls-stream-inf-without-uri"
+	RULE_XSPF_TRACK_WITHOUT_LOCATION = "xspf-track-without-location"
+)
+
+// Validator is implemented by parsers which check playlists against
+// the rules of their format. All built in parsers implement it.
+type Validator interface {
+	// Validate returns diagnostics and validation findings.
+	Validate() []*Diagnostic
+}
+
+// Validate checks parsed playlist against its format rules. If rules
+// are given only findings with those rule identifiers are returned.
+// Must be called after Parse.
+func (p *Playlist) Validate(rules ...string) []*Diagnostic {
+
+	var all []*Diagnostic
+
+	switch parser := p.Parser.(type) {
+	case nil:
+		all = []*Diagnostic{newDiagnostic(0, 0, SeverityError, RULE_UNKNOWN_FORMAT, "Unknown playlist format", p.firstLine)}
+	case Validator:
+		all = parser.Validate()
+	default:
+		all = parser.GetDiagnostics()
+	}
+
+	if len(rules) == 0 {
+		return all
+	}
+
+	enabled := make(map[string]bool, len(rules))
+	for _, rule := range rules {
+		enabled[rule] = true
+	}
+
+	findings := make([]*Diagnostic, 0, len(all))
+	for _, d := range all {
+		if enabled[d.Rule] {
+			findings = append(findings, d)
+		}
+	}
+
+	return findings
+}
+
+// checkIndexGaps adds RULE_INDEX_GAP finding for every range of indexes
+// missing in 1 to max(indexes) range. The prefix is the key name like
+// File or Ref.
+func checkIndexGaps(d *Diagnostics, prefix string, indexes map[int]bool) {
+
+	keys := make([]int, 0, len(indexes))
+	for idx := range indexes {
+		if idx > 0 {
+			keys = append(keys, idx)
+		}
+	}
+	sort.Ints(keys)
+
+	// Walk present indexes only so huge indexes do not cost anything
+	prev := 0
+	for _, idx := range keys {
+
+		switch {
+		case idx == prev+2:
+			d.AddFinding(0, 0, SeverityWarning, RULE_INDEX_GAP, "Missing "+prefix+strconv.Itoa(prev+1)+" entry", "")
+		case idx > prev+2:
+			d.AddFinding(0, 0, SeverityWarning, RULE_INDEX_GAP,
+				"Missing "+prefix+strconv.Itoa(prev+1)+" to "+prefix+strconv.Itoa(idx-1)+" entries", "")
+		}
+
+		prev = idx
+	}
+}
@@ -0,0 +1,119 @@
+// Part of the Go playlist parser package
+//
+// Copyright 2013 Rafal Zajac rzajac<at>gmail<dot>com. All rights reserved.
+// http://github.com/rzajac/plparser
+//
+// Licensed under the MIT license
+
+package plparser
+
+import (
+	"testing"
+)
+
+func TestValidatorFindings(t *testing.T) {
+
+	var tests = []struct {
+		name   string
+		parser Playlister
+		rule   string
+		line   int
+	}{
+		{"pls missing entries", NewPlsParser([]byte("[playlist]\nFile1=http://ex.com/\nVersion=2\n")), RULE_PLS_MISSING_ENTRIES, 0},
+		{"pls entries mismatch", NewPlsParser([]byte("[playlist]\nFile1=http://ex.com/\nNumberOfEntries=2\nVersion=2\n")), RULE_PLS_ENTRIES_MISMATCH, 3},
+		{"pls missing version", NewPlsParser([]byte("[playlist]\nFile1=http://ex.com/\nNumberOfEntries=1\n")), RULE_PLS_MISSING_VERSION, 0},
+		{"pls index gap", NewPlsParser([]byte("[playlist]\nFile1=http://ex.com/\nFile3=http://ex3.com/\nNumberOfEntries=2\nVersion=2\n")), RULE_INDEX_GAP, 0},
+		{"asf index gap", NewAsfParser([]byte("[Reference]\nRef2=http://ex.com/\n")), RULE_INDEX_GAP, 0},
+		{"asx missing version", NewAsxParser([]byte("<asx>\n<entry><ref href=\"http://ex.com/\"/></entry>\n</asx>")), RULE_ASX_MISSING_VERSION, 1},
+		{"asx ref outside entry", NewAsxParser([]byte("<asx version=\"3.0\">\n<entry><ref href=\"http://ex.com/\"/></entry>\n<ref href=\"http://ex2.com/\"/>\n</asx>")), RULE_ASX_REF_OUTSIDE_ENTRY, 3},
+		{"hls missing extm3u", NewHlsParser([]byte("#EXT-X-TARGETDURATION:10\n#EXTINF:10,\nseg1.ts\n")), RULE_HLS_MISSING_EXTM3U, 1},
+		{"hls tag order", NewHlsParser([]byte("#EXTM3U\n#EXT-X-TARGETDURATION:10\n#EXTINF:10,\nseg1.ts\n#EXT-X-VERSION:3\n")), RULE_HLS_TAG_ORDER, 5},
+		{"hls missing target duration", NewHlsParser([]byte("#EXTM3U\n#EXTINF:10,\nseg1.ts\n")), RULE_HLS_MISSING_TARGET_DURATION, 0},
+	}
+
+	for _, test := range tests {
+
+		if err := test.parser.Parse(); err != nil {
+			t.Fatalf("Unexpected error %s (%s)", err, test.name)
+		}
+
+		if len(test.parser.GetDiagnostics()) != 0 {
+			t.Fatalf("Expected findings not to be parse diagnostics (%s): %v", test.name, test.parser.GetDiagnostics())
+		}
+
+		findings := test.parser.(Validator).Validate()
+		if len(findings) != 1 {
+			t.Fatalf("Expected 1 finding got %d (%s): %v", len(findings), test.name, findings)
+		}
+
+		if findings[0].Rule != test.rule || findings[0].Line != test.line {
+			t.Fatalf("Unexpected finding %s (%s)", findings[0], test.name)
+		}
+	}
+}
+
+func TestValidateIndexGapRanges(t *testing.T) {
+
+	parser := NewPlsParser([]byte("[playlist]\nFile1=http://ex.com/1\nFile3=http://ex.com/3\nFile30000000=http://ex.com/big\nNumberOfEntries=3\nVersion=2\n"))
+	parser.Parse()
+
+	findings := parser.Validate()
+	if len(findings) != 2 {
+		t.Fatalf("Expected 2 findings got %d", len(findings))
+	}
+
+	if findings[0].Message != "Missing File2 entry" || findings[1].Message != "Missing File4 to File29999999 entries" {
+		t.Fatalf("Unexpected findings %v", findings)
+	}
+}
+
+func TestValidateNoFindings(t *testing.T) {
+
+	var tests = []string{"asx2.asx", "hls1.m3u8", "hls2.m3u8", "xspf1.xspf"}
+
+	for _, test := range tests {
+
+		plr := new(PlaylistResp)
+		plr.Raw = getPLFile("./testpls/" + test)
+
+		pl := NewPlaylist(plr)
+		pl.Parse()
+
+		if findings := pl.Validate(); len(findings) != 0 {
+			t.Fatalf("Expected no findings for %s got %v", test, findings)
+		}
+	}
+}
+
+func TestValidateRules(t *testing.T) {
+
+	plr := new(PlaylistResp)
+	plr.Raw = []byte("[playlist]\nFile1=stream.mp3\nFile3=http://ex.com/\n")
+
+	pl := NewPlaylist(plr)
+	pl.Parse()
+
+	all := pl.Validate()
+	if len(all) != 4 {
+		t.Fatalf("Expected 4 findings got %d: %v", len(all), all)
+	}
+
+	findings := pl.Validate(RULE_NOT_URL, RULE_INDEX_GAP)
+	if len(findings) != 2 || findings[0].Rule != RULE_INDEX_GAP || findings[1].Rule != RULE_NOT_URL {
+		t.Fatalf("Expected index gap and not URL findings got %v", findings)
+	}
+}
+
+func TestValidateUnknownFormat(t *testing.T) {
+
+	plr := new(PlaylistResp)
+	plr.Raw = []byte("just text\n")
+
+	pl := NewPlaylist(plr)
+	pl.Parse()
+
+	findings := pl.Validate()
+	if len(findings) != 1 || findings[0].Rule != RULE_UNKNOWN_FORMAT || findings[0].Severity != SeverityError {
+		t.Fatalf("Expected unknown format finding got %v", findings)
+	}
+}
@@ -0,0 +1,199 @@
+// Part of the Go playlist parser package
+//
+// Copyright 2013 Rafal Zajac rzajac<at>gmail<dot>com. All rights reserved.
+// http://github.com/rzajac/plparser
+//
+// Licensed under the MIT license
+
+package plparser
+
+import (
+	"bytes"
+	"encoding/xml"
+	"strconv"
+	"strings"
+	"time"
+)
+
+func init() {
+	RegisterFormat(&Format{
+		Name:       "wpl",
+		MimeTypes:  []string{"application/vnd.ms-wpl"},
+		Extensions: []string{".wpl"},
+		Detect:     detectWpl,
+		New:        func(raw []byte) Playlister { return NewWplParser(raw) },
+	})
+
+	RegisterFormat(&Format{
+		Name:       "zpl",
+		MimeTypes:  []string{"application/vnd.ms-zpl"},
+		Extensions: []string{".zpl"},
+		Detect:     detectZpl,
+		New:        func(raw []byte) Playlister { return NewWplParser(raw) },
+	})
+}
+
+// wplMeta represents META element of WPL playlist head.
+type wplMeta struct {
+	Name    string `xml:"name,attr"`
+	Content string `xml:"content,attr"`
+}
+
+// wplMedia represents MEDIA element of WPL playlist.
+type wplMedia struct {
+	Src         string `xml:"src,attr"`
+	TrackTitle  string `xml:"trackTitle,attr"`
+	TrackArtist string `xml:"trackArtist,attr"`
+	AlbumTitle  string `xml:"albumTitle,attr"`
+	Duration    string `xml:"duration,attr"` // Milliseconds, ZPL only
+}
+
+// wplSmil represents SMIL element of WPL playlist.
+type wplSmil struct {
+	Title  string     `xml:"head>title"`
+	Author string     `xml:"head>author"`
+	Meta   []wplMeta  `xml:"head>meta"`
+	Media  []wplMedia `xml:"body>seq>media"`
+}
+
+// WplParser implements Windows Media Player (WPL) and Zune (ZPL)
+// playlist parser.
+type WplParser struct {
+	Diagnostics
+	UrlResolver
+	raw       []byte
+	Author    string
+	Meta      map[string]string // META elements of the playlist head
+	ItemCount int               // -1 if missing
+	Streams   []*Stream
+	Title     string
+}
+
+// NewWplParser returns new WPL / ZPL playlist parser. Takes playlist raw content to parse.
+func NewWplParser(raw []byte) *WplParser {
+	wpl := new(WplParser)
+	wpl.raw = raw
+	wpl.ItemCount = -1
+	wpl.Meta = make(map[string]string)
+	wpl.Streams = make([]*Stream, 0, 10)
+	return wpl
+}
+
+// Parse parses a WPL or ZPL playlist. Only media in the SEQ element are
+// returned, smart playlist queries are not evaluated.
+func (p *WplParser) Parse() error {
+
+	var pl wplSmil
+
+	decoder := xml.NewDecoder(bytes.NewReader(p.raw))
+	decoder.Strict = false
+	decoder.Entity = xml.HTMLEntity
+	decoder.CharsetReader = xmlCharsetReader
+
+	if err := decoder.Decode(&pl); err != nil {
+		var line int
+		if serr, ok := err.(*xml.SyntaxError); ok {
+			line = serr.Line
+		}
+
+		p.AddDiagnostic(line, 0, SeverityError, RULE_MALFORMED_XML, "Malformed XML: "+err.Error(), "")
+		return &ParseError{Line: line, Err: err}
+	}
+
+	p.Title = fixString(pl.Title)
+	p.Author = fixString(pl.Author)
+
+	for _, meta := range pl.Meta {
+		if name := fixString(meta.Name); name != "" {
+			p.Meta[name] = fixString(meta.Content)
+		}
+	}
+
+	if count, err := strconv.Atoi(p.Meta["ItemCount"]); err == nil {
+		p.ItemCount = count
+	}
+
+	for idx, media := range pl.Media {
+
+		src := fixString(media.Src)
+		if src == "" {
+			p.AddDiagnostic(0, 0, SeverityWarning, RULE_NOT_URL, "Media "+strconv.Itoa(idx+1)+" without src", "")
+			continue
+		}
+
+		s := NewStream(idx + 1)
+		s.Title = fixString(media.TrackTitle)
+		s.Author = fixString(media.TrackArtist)
+		s.Album = fixString(media.AlbumTitle)
+
+		if ms, err := strconv.ParseInt(fixString(media.Duration), 10, 64); err == nil {
+			s.Duration = time.Duration(ms) * time.Millisecond
+		}
+
+		s.OriginalUrl = src
+		s.Url = p.resolveUrl(wplSrc(src))
+
+		if p.checkStream(&p.Diagnostics, s, 0, 0, src) {
+			p.Streams = append(p.Streams, s)
+		}
+	}
+
+	return nil
+}
+
+// GetStreams gets list of streams found in the playlist.
+func (p *WplParser) GetStreams() []*Stream {
+	return p.Streams
+}
+
+// GetInfo gets playlist level metadata.
+func (p *WplParser) GetInfo() *PlaylistInfo {
+
+	info := &PlaylistInfo{
+		Title:  p.Title,
+		Author: p.Author,
+	}
+
+	if p.ItemCount != -1 {
+		info.Entries = p.ItemCount
+	}
+
+	if len(p.Meta) > 0 {
+		info.Attributes = make(map[string]string, len(p.Meta))
+		for k, v := range p.Meta {
+			info.Attributes[k] = v
+		}
+	}
+
+	return info
+}
+
+// wplSrc returns URL reference for media src. Windows paths are
+// converted to file URLs and backslashes in relative paths to slashes.
+func wplSrc(src string) string {
+
+	switch {
+	case strings.HasPrefix(src, `\\`):
+		// UNC path \\server\share\file
+		return "file:" + strings.Replace(src, `\`, "/", -1)
+
+	case len(src) > 2 && src[1] == ':' && (src[2] == '\\' || src[2] == '/'):
+		// Drive path C:\dir\file
+		return "file:///" + strings.Replace(src, `\`, "/", -1)
+
+	case isAbsUrl(src):
+		return src
+	}
+
+	return strings.Replace(src, `\`, "/", -1)
+}
+
+// detectWpl returns true if playlist is a WPL playlist.
+func detectWpl(header string, raw []byte) bool {
+	return strings.HasPrefix(header, "<?wpl")
+}
+
+// detectZpl returns true if playlist is a ZPL playlist.
+func detectZpl(header string, raw []byte) bool {
+	return strings.HasPrefix(header, "<?zpl")
+}
@@ -0,0 +1,122 @@
+// Part of the Go playlist parser package
+//
+// Copyright 2013 Rafal Zajac rzajac<at>gmail<dot>com. All rights reserved.
+// http://github.com/rzajac/plparser
+//
+// Licensed under the MIT license
+
+package plparser
+
+import (
+	"testing"
+	"time"
+)
+
+func TestWplFiles(t *testing.T) {
+
+	var tests = map[string][]struct {
+		index    int
+		title    string
+		author   string
+		album    string
+		duration time.Duration
+		url      string
+	}{
+		"./testpls/wpl1.wpl": {
+			{1, "First", "Artist", "Album", 215 * time.Second, "http://ex.com/Music/Artist/01%20First.mp3"},
+			{2, "", "", "", 0, "file:///C:/Users/Public/Music/02%20Second.wma"},
+			{3, "", "", "", 0, "file://server/share/03%20Third.mp3"},
+			{4, "", "", "", 0, "http://live.example.com:8881/"},
+		},
+		"./testpls/zpl1.zpl": {
+			{1, "One", "Artist", "Album", 180500 * time.Millisecond, "http://ex.com/lists/Music/Artist/One.mp3"},
+			{2, "Live", "", "", 0, "http://live.example.com:8882/"},
+		},
+	}
+
+	for filePath, expected := range tests {
+
+		parser := NewWplParser(getPLFile(filePath))
+		parser.SetBaseUrl("http://ex.com/lists/playlist")
+
+		if err := parser.Parse(); err != nil {
+			t.Fatalf("Unexpected error %s (%s)", err, filePath)
+		}
+
+		if len(parser.Streams) != len(expected) {
+			t.Fatalf("Expected %d streams got %d (%s)", len(expected), len(parser.Streams), filePath)
+		}
+
+		for i, e := range expected {
+			s := parser.Streams[i]
+			if s.Index != e.index || s.Title != e.title || s.Author != e.author || s.Album != e.album || s.Duration != e.duration || s.Url != e.url {
+				t.Fatalf("Unexpected stream %d %+v (%s)", e.index, s, filePath)
+			}
+		}
+
+		if diags := parser.GetDiagnostics(); len(diags) != 0 {
+			t.Fatalf("Expected no diagnostics got %v (%s)", diags, filePath)
+		}
+	}
+}
+
+func TestWplInfo(t *testing.T) {
+
+	parser := NewWplParser(getPLFile("./testpls/wpl1.wpl"))
+	parser.Parse()
+
+	info := parser.GetInfo()
+	if info.Title != "Rock & Roll" || info.Author != "MAU" || info.Entries != 4 {
+		t.Fatalf("Unexpected info %+v", info)
+	}
+
+	if info.Attributes["Generator"] != "Microsoft Windows Media Player -- 12.0.7601.17514" {
+		t.Fatalf("Expected Generator meta got %v", info.Attributes)
+	}
+}
+
+func TestWplDetect(t *testing.T) {
+
+	var tests = map[string]string{
+		"./testpls/wpl1.wpl": "wpl",
+		"./testpls/zpl1.zpl": "zpl",
+	}
+
+	for filePath, expected := range tests {
+
+		plr := new(PlaylistResp)
+		plr.Raw = getPLFile(filePath)
+
+		pl := NewPlaylist(plr)
+		pltype, err := pl.Parse()
+		if err != nil {
+			t.Fatalf("Unexpected error %s (%s)", err, filePath)
+		}
+
+		if pltype != expected {
+			t.Fatalf("Expected playlist type %s got %s (%s)", expected, pltype, filePath)
+		}
+
+		if _, ok := pl.Parser.(*WplParser); !ok {
+			t.Fatalf("Expected *WplParser (%s)", filePath)
+		}
+	}
+}
+
+func TestWplSrc(t *testing.T) {
+
+	var tests = map[string]string{
+		`..\Music\a.mp3`:       "../Music/a.mp3",
+		`C:\Music\a.mp3`:       "file:///C:/Music/a.mp3",
+		`\\server\share\a.mp3`: "file://server/share/a.mp3",
+		"http://ex.com/a.mp3":  "http://ex.com/a.mp3",
+		"mms://ex.com/live":    "mms://ex.com/live",
+		"Music/a.mp3":          "Music/a.mp3",
+	}
+
+	for src, expected := range tests {
+		if u := wplSrc(src); u != expected {
+			t.Fatalf("Expected %s got %s (%s)", expected, u, src)
+		}
+	}
+}
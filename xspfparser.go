@@ -0,0 +1,184 @@
+// Part of the Go playlist parser package
+//
+// Copyright 2013 Rafal Zajac rzajac<at>gmail<dot>com. All rights reserved.
+// http://github.com/rzajac/plparser
+//
+// Licensed under the MIT license
+
+package plparser
+
+import (
+	"bytes"
+	"encoding/xml"
+	"strconv"
+	"strings"
+	"time"
+)
+
+func init() {
+	RegisterFormat(&Format{
+		Name:       "xspf",
+		MimeTypes:  []string{"application/xspf+xml"},
+		Extensions: []string{".xspf"},
+		Detect:     detectXspf,
+		New:        func(raw []byte) Playlister { return NewXspfParser(raw) },
+	})
+}
+
+// xspfNamespace is the XML namespace of XSPF version 1 playlists.
+const xspfNamespace = "http://xspf.org/ns/0/"
+
+// xspfTrack represents TRACK element of XSPF playlist.
+type xspfTrack struct {
+	Location   []string `xml:"location"`
+	Title      string   `xml:"title"`
+	Creator    string   `xml:"creator"`
+	Annotation string   `xml:"annotation"`
+	Image      string   `xml:"image"`
+	Info       string   `xml:"info"`
+	Album      string   `xml:"album"`
+	Duration   string   `xml:"duration"`
+	Base       string   `xml:"http://www.w3.org/XML/1998/namespace base,attr"`
+}
+
+// xspfPlaylist represents PLAYLIST element of XSPF playlist.
+type xspfPlaylist struct {
+	Title      string      `xml:"title"`
+	Creator    string      `xml:"creator"`
+	Annotation string      `xml:"annotation"`
+	Image      string      `xml:"image"`
+	Info       string      `xml:"info"`
+	Version    string      `xml:"version,attr"`
+	Tracks     []xspfTrack `xml:"trackList>track"`
+	Base       string      `xml:"http://www.w3.org/XML/1998/namespace base,attr"`
+}
+
+// XspfParser implements XSPF playlist parser.
+type XspfParser struct {
+	Diagnostics
+	UrlResolver
+	raw         []byte
+	Author      string
+	Base        string
+	Description string
+	Logo        string
+	MoreInfo    string
+	Streams     []*Stream
+	Title       string
+	Version     string
+}
+
+// NewXspfParser returns new XSPF playlist parser. Takes playlist raw content to parse.
+func NewXspfParser(raw []byte) *XspfParser {
+	xspf := new(XspfParser)
+	xspf.raw = raw
+	xspf.Streams = make([]*Stream, 0, 10)
+	return xspf
+}
+
+// Parse parses a XSPF playlist.
+func (p *XspfParser) Parse() error {
+
+	var pl xspfPlaylist
+
+	decoder := xml.NewDecoder(bytes.NewReader(p.raw))
+	decoder.Strict = false
+	decoder.CharsetReader = xmlCharsetReader
+
+	if err := decoder.Decode(&pl); err != nil {
+		var line int
+		if serr, ok := err.(*xml.SyntaxError); ok {
+			line = serr.Line
+		}
+
+		p.AddDiagnostic(line, 0, SeverityError, RULE_MALFORMED_XML, "Malformed XML: "+err.Error(), "")
+		return &ParseError{Line: line, Err: err}
+	}
+
+	p.Title = fixString(pl.Title)
+	p.Author = fixString(pl.Creator)
+	p.Description = fixString(pl.Annotation)
+	p.Logo = fixString(pl.Image)
+	p.MoreInfo = fixString(pl.Info)
+	p.Base = fixString(pl.Base)
+	p.Version = fixString(pl.Version)
+
+	for idx, track := range pl.Tracks {
+
+		s := NewStream(idx + 1)
+		s.Title = fixString(track.Title)
+		s.Author = fixString(track.Creator)
+		s.Description = fixString(track.Annotation)
+		s.Logo = fixString(track.Image)
+		s.MoreInfo = fixString(track.Info)
+		s.Album = fixString(track.Album)
+
+		// XSPF duration is in milliseconds
+		if ms, err := strconv.ParseInt(fixString(track.Duration), 10, 64); err == nil {
+			s.Duration = time.Duration(ms) * time.Millisecond
+		}
+
+		if len(track.Location) == 0 {
+			p.AddDiagnostic(0, 0, SeverityWarning, RULE_XSPF_TRACK_WITHOUT_LOCATION, "Track "+strconv.Itoa(idx+1)+" without location", s.Title)
+		}
+
+		// The first accepted location is the track URL,
+		// the others are its fallbacks
+		var primary *Stream
+
+		for _, location := range track.Location {
+
+			location = fixString(location)
+			if location == "" {
+				continue
+			}
+
+			newStream := s.makeCopy()
+			newStream.OriginalUrl = location
+			newStream.Url = p.resolveUrl(location, track.Base, pl.Base)
+
+			if !p.checkStream(&p.Diagnostics, newStream, 0, 0, location) {
+				continue
+			}
+
+			if primary == nil {
+				primary = newStream
+				p.Streams = append(p.Streams, primary)
+			} else {
+				primary.Fallbacks = append(primary.Fallbacks, newStream.Url)
+			}
+		}
+	}
+
+	return nil
+}
+
+// GetStreams gets list of streams found in the playlist.
+func (p *XspfParser) GetStreams() []*Stream {
+	return p.Streams
+}
+
+// GetInfo gets playlist level metadata.
+func (p *XspfParser) GetInfo() *PlaylistInfo {
+	return &PlaylistInfo{
+		Title:       p.Title,
+		Author:      p.Author,
+		Description: p.Description,
+		Logo:        p.Logo,
+		MoreInfo:    p.MoreInfo,
+		Base:        p.Base,
+		Version:     p.Version,
+	}
+}
+
+// detectXspf returns true if playlist is a XSPF playlist.
+func detectXspf(header string, raw []byte) bool {
+
+	if !(strings.HasPrefix(header, "<?xml") || strings.HasPrefix(header, "<playlist")) {
+		return false
+	}
+
+	name, space := xmlRoot(raw)
+
+	return name == "playlist" && strings.HasPrefix(space, xspfNamespace)
+}
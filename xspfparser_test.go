@@ -0,0 +1,165 @@
+// Part of the Go playlist parser package
+//
+// Copyright 2013 Rafal Zajac rzajac<at>gmail<dot>com. All rights reserved.
+// http://github.com/rzajac/plparser
+//
+// Licensed under the MIT license
+
+package plparser
+
+import (
+	"testing"
+	"time"
+)
+
+func TestXspfFiles(t *testing.T) {
+
+	var xspf1 = map[int]plTestStruct{
+		0: {},
+		1: {1, "E1T", "E1A", "http://E1.ex.com/l.gif", "E1AU", "", "http://E1.mi.ex.com", "http://live1.example.com:8881/stream"},
+		2: {2, "E2T & more", "", "", "", "", "", "http://live2.example.com:8882/song.mp3"},
+	}
+
+	var xspf2 = map[int]plTestStruct{
+		0: {},
+		1: {1, "", "", "", "", "", "", "http://live1.example.com:8881/stream"},
+	}
+
+	var testFiles = map[string]map[int]plTestStruct{
+		"./testpls/xspf1.xspf": xspf1,
+		"./testpls/xspf2.xspf": xspf2,
+	}
+
+	for filePath, rulez := range testFiles {
+		t.Logf("Testing %s", filePath)
+		testXspfFile(filePath, rulez, t)
+	}
+}
+
+func TestXspfExtraFields(t *testing.T) {
+
+	parser := NewXspfParser(getPLFile("./testpls/xspf1.xspf"))
+	parser.Parse()
+
+	if parser.Title != "MT" || parser.Author != "MAU" || parser.Description != "MA" {
+		t.Fatalf("Unexpected playlist header '%s', '%s', '%s'", parser.Title, parser.Author, parser.Description)
+	}
+
+	if parser.Logo != "http://ml.ex.com/l.gif" || parser.MoreInfo != "http://mi.ex.com/mi" {
+		t.Fatalf("Unexpected playlist header '%s', '%s'", parser.Logo, parser.MoreInfo)
+	}
+
+	if parser.Streams[0].Album != "E1AL" {
+		t.Fatalf("Expected album 'E1AL' == '%s'", parser.Streams[0].Album)
+	}
+
+	if parser.Streams[0].Duration != 0 {
+		t.Fatalf("Expected duration 0 == %v", parser.Streams[0].Duration)
+	}
+
+	if parser.Streams[1].Duration != 215*time.Second {
+		t.Fatalf("Expected duration %v == %v", 215*time.Second, parser.Streams[1].Duration)
+	}
+}
+
+func testXspfFile(filePath string, rulez map[int]plTestStruct, t *testing.T) {
+
+	rawPlaylist := getPLFile(filePath)
+
+	parser := NewXspfParser(rawPlaylist)
+	parser.Parse()
+
+	expectedStreamCount := len(rulez) - 1
+	streamCount := len(parser.Streams)
+
+	if expectedStreamCount != streamCount {
+		t.Fatalf("Expected %d streams got %d (%s)", expectedStreamCount, streamCount, filePath)
+	}
+
+	// Map of tested streams
+	keys := make(map[int]bool, 10)
+
+	for _, stream := range parser.Streams {
+
+		expected, ok := rulez[stream.Index]
+
+		if !ok {
+			t.Fatalf("Stream with index %d was not expected", stream.Index)
+		}
+
+		keys[stream.Index] = true
+
+		if expected.Title != stream.Title {
+			t.Fatalf("Expected stream (%s:%d) Title '%s' == '%s'", filePath, stream.Index, expected.Title, stream.Title)
+		}
+
+		if expected.Description != stream.Description {
+			t.Fatalf("Expected stream (%s:%d) Description '%s' == '%s'", filePath, stream.Index, expected.Description, stream.Description)
+		}
+
+		if expected.Logo != stream.Logo {
+			t.Fatalf("Expected stream (%s:%d) Logo '%s' == '%s'", filePath, stream.Index, expected.Logo, stream.Logo)
+		}
+
+		if expected.Author != stream.Author {
+			t.Fatalf("Expected stream (%s:%d) Author '%s' == '%s'", filePath, stream.Index, expected.Author, stream.Author)
+		}
+
+		if expected.MoreInfo != stream.MoreInfo {
+			t.Fatalf("Expected stream (%s:%d) MoreInfo '%s' == '%s'", filePath, stream.Index, expected.MoreInfo, stream.MoreInfo)
+		}
+
+		if expected.Url != stream.Url {
+			t.Fatalf("Expected stream (%s:%d) Url '%s' == '%s'", filePath, stream.Index, expected.Url, stream.Url)
+		}
+	}
+
+	if len(keys) != len(rulez)-1 {
+		t.Fatalf("Not all streams has been tested")
+	}
+}
+
+func BenchmarkXspfParsing(b *testing.B) {
+
+	testFile := getPLFile("./testpls/xspf1.xspf")
+
+	for i := 0; i < b.N; i++ {
+		parser := NewXspfParser(testFile)
+		parser.Parse()
+	}
+}
+
+func TestXspfLocationFallbacks(t *testing.T) {
+
+	raw := `<?xml version="1.0" encoding="UTF-8"?>
+<playlist version="1" xmlns="http://xspf.org/ns/0/">
+	<trackList>
+		<track>
+			<title>One</title>
+			<location>http://live1.example.com/stream</location>
+			<location>http://live2.example.com/stream</location>
+			<location>http://live3.example.com/stream</location>
+		</track>
+		<track><location>http://live4.example.com/stream</location></track>
+	</trackList>
+</playlist>`
+
+	parser := NewXspfParser([]byte(raw))
+	if err := parser.Parse(); err != nil {
+		t.Fatalf("Unexpected error %s", err)
+	}
+
+	if len(parser.Streams) != 2 {
+		t.Fatalf("Expected 2 streams got %d", len(parser.Streams))
+	}
+
+	s := parser.Streams[0]
+	if s.Index != 1 || s.Title != "One" || s.Url != "http://live1.example.com/stream" || len(s.Fallbacks) != 2 ||
+		s.Fallbacks[0] != "http://live2.example.com/stream" || s.Fallbacks[1] != "http://live3.example.com/stream" {
+		t.Fatalf("Expected stream with 2 fallbacks got %+v", s)
+	}
+
+	if s = parser.Streams[1]; s.Index != 2 || s.Fallbacks != nil {
+		t.Fatalf("Expected stream 2 with no fallbacks got %+v", s)
+	}
+}